@@ -0,0 +1,427 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var slugInvalidPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+func slugify(value string) string {
+	return strings.Trim(slugInvalidPattern.ReplaceAllString(strings.ToLower(value), "-"), "-")
+}
+
+func uniqueArticleSlug(base string, articleId int64) (string, error) {
+	if base == "" {
+		base = "article"
+	}
+
+	slug := base
+
+	for suffix := 2; ; suffix++ {
+		var count int
+
+		var countError = database.Model(Article{}).
+			Where("slug = ?", slug).
+			Where("id <> ?", articleId).
+			Count(&count).
+			Error
+
+		if countError != nil {
+			return "", countError
+		}
+
+		if count == 0 {
+			return slug, nil
+		}
+
+		slug = fmt.Sprintf("%s-%d", base, suffix)
+	}
+}
+
+func preloadArticleAuthor(query *gorm.DB) *gorm.DB {
+	return query.
+		Preload("Author").
+		Preload("Author.Data").
+		Preload("Author.AvatarData").
+		Preload("Author.PrivacySettings")
+}
+
+func publishedArticles() *gorm.DB {
+	return database.Model(Article{}).
+		Where("published_at IS NOT NULL").
+		Where("published_at <= ?", time.Now().In(location))
+}
+
+func ArticlesHandler(w http.ResponseWriter, r *http.Request) {
+	cursor, cursorError := decodeCursor(r.URL.Query().Get("cursor"))
+
+	if cursorError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid cursor"})
+		return
+	}
+
+	limit := parseLimit(r, 10, 50)
+	query := preloadArticleAuthor(publishedArticles())
+
+	if cursor != nil {
+		publishedAt, err := time.Parse(time.RFC3339Nano, cursor.Value)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid cursor"})
+			return
+		}
+
+		query = query.Where("published_at < ? OR (published_at = ? AND id < ?)", publishedAt, publishedAt, cursor.ID)
+	}
+
+	var articles []Article
+
+	var queryError = query.
+		Order("published_at DESC").
+		Order("id DESC").
+		Limit(limit + 1).
+		Find(&articles).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	nextCursor := ""
+
+	if len(articles) > limit {
+		articles = articles[:limit]
+		last := articles[len(articles)-1]
+		nextCursor = encodeCursor(pageCursor{Value: last.PublishedAt.Format(time.RFC3339Nano), ID: last.ID})
+	}
+
+	responses := make([]ArticleSummaryResponse, 0, len(articles))
+
+	for _, article := range articles {
+		responses = append(responses, newArticleSummaryResponse(article))
+	}
+
+	encodePage(w, r, responses, nextCursor)
+}
+
+func ArticleHandler(w http.ResponseWriter, r *http.Request) {
+	var article Article
+
+	var queryError = preloadArticleAuthor(publishedArticles()).
+		Where("slug = ?", mux.Vars(r)["slug"]).
+		First(&article).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested article couldn't be found"})
+		return
+	}
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	reactions, reactionsError := loadReactionCounts(article.ID, []int64{0})
+
+	if reactionsError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: reactionsError.Error()})
+		return
+	}
+
+	response := newArticleResponse(article)
+	response.Reactions = reactions[0]
+
+	encodeResponse(w, r, response)
+}
+
+func ArticlePreviewHandler(w http.ResponseWriter, r *http.Request) {
+	var article Article
+
+	var queryError = preloadArticleAuthor(database.Model(Article{})).
+		Where("preview_token = ?", mux.Vars(r)["token"]).
+		First(&article).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested article couldn't be found"})
+		return
+	}
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	encodeResponse(w, r, newArticleResponse(article))
+}
+
+func AdminArticlesHandler(w http.ResponseWriter, r *http.Request) {
+	cursor, cursorError := decodeCursor(r.URL.Query().Get("cursor"))
+
+	if cursorError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid cursor"})
+		return
+	}
+
+	limit := parseLimit(r, 25, 100)
+	query := preloadArticleAuthor(database.Model(Article{}))
+
+	if cursor != nil {
+		query = query.Where("id < ?", cursor.ID)
+	}
+
+	var articles []Article
+
+	var queryError = query.
+		Order("id DESC").
+		Limit(limit + 1).
+		Find(&articles).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	nextCursor := ""
+
+	if len(articles) > limit {
+		articles = articles[:limit]
+		nextCursor = encodeCursor(pageCursor{ID: articles[len(articles)-1].ID})
+	}
+
+	responses := make([]AdminArticleResponse, 0, len(articles))
+
+	for _, article := range articles {
+		responses = append(responses, newAdminArticleResponse(article))
+	}
+
+	encodePage(w, r, responses, nextCursor)
+}
+
+func CreateArticleHandler(w http.ResponseWriter, r *http.Request) {
+	var req ArticleRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	author, authorError := getAuthenticatedPlayer(r)
+
+	if authorError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: authorError.Error()})
+		return
+	}
+
+	seedRandom()
+
+	article := Article{
+		AuthorId:     author.ID,
+		PreviewToken: randSeq(30),
+		CreatedAt:    time.Now().In(location),
+	}
+
+	saveArticle(w, r, article, req)
+}
+
+func UpdateArticleHandler(w http.ResponseWriter, r *http.Request) {
+	article, found := findArticleParam(w, r)
+
+	if !found {
+		return
+	}
+
+	var req ArticleRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	saveArticle(w, r, article, req)
+}
+
+func saveArticle(w http.ResponseWriter, r *http.Request, article Article, req ArticleRequest) {
+	base := slugify(req.Slug)
+
+	if base == "" {
+		base = slugify(req.Title)
+	}
+
+	slug, slugError := uniqueArticleSlug(base, article.ID)
+
+	if slugError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: slugError.Error()})
+		return
+	}
+
+	article.Title = req.Title
+	article.Slug = slug
+	article.Summary = req.Summary
+	article.Body = req.Body
+	article.BodyHtml = renderMarkdown(req.Body)
+	article.HeaderImageUrl = req.HeaderImageUrl
+	article.UpdatedAt = time.Now().In(location)
+
+	if err := database.Set("gorm:save_associations", false).Save(&article).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	respondWithAdminArticle(w, r, article.ID)
+}
+
+func PublishArticleHandler(w http.ResponseWriter, r *http.Request) {
+	article, found := findArticleParam(w, r)
+
+	if !found {
+		return
+	}
+
+	var req ArticlePublishRequest
+
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+			return
+		}
+	}
+
+	actor, actorError := getAuthenticatedPlayer(r)
+
+	if actorError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: actorError.Error()})
+		return
+	}
+
+	publishAt := time.Now().In(location)
+
+	if req.PublishAt != nil {
+		publishAt = req.PublishAt.In(location)
+	}
+
+	if err := database.Model(&article).Update("published_at", publishAt).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	recordAudit(r, &actor.ID, nil, auditArticlePublished, map[string]interface{}{"article_id": article.ID, "publish_at": publishAt})
+
+	respondWithAdminArticle(w, r, article.ID)
+}
+
+func UnpublishArticleHandler(w http.ResponseWriter, r *http.Request) {
+	article, found := findArticleParam(w, r)
+
+	if !found {
+		return
+	}
+
+	actor, actorError := getAuthenticatedPlayer(r)
+
+	if actorError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: actorError.Error()})
+		return
+	}
+
+	if err := database.Model(&article).Update("published_at", gorm.Expr("NULL")).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	recordAudit(r, &actor.ID, nil, auditArticleUnpublished, map[string]interface{}{"article_id": article.ID})
+
+	respondWithAdminArticle(w, r, article.ID)
+}
+
+func findArticleParam(w http.ResponseWriter, r *http.Request) (Article, bool) {
+	var article Article
+
+	articleId, idError := parseIdParam(r, "id")
+
+	if idError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid article id"})
+		return article, false
+	}
+
+	var queryError = database.Model(Article{}).
+		Where("id = ?", articleId).
+		First(&article).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested article couldn't be found"})
+		return article, false
+	}
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return article, false
+	}
+
+	return article, true
+}
+
+func respondWithAdminArticle(w http.ResponseWriter, r *http.Request, articleId int64) {
+	var article Article
+
+	var queryError = preloadArticleAuthor(database.Model(Article{})).
+		Where("id = ?", articleId).
+		First(&article).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	encodeResponse(w, r, newAdminArticleResponse(article))
+}
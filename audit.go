@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	auditLogin                  = "login"
+	auditLoginFailed            = "login.failed"
+	auditPasswordChanged        = "password.changed"
+	auditEmailChanged           = "email.changed"
+	auditPasswordResetRequested = "password_reset.requested"
+	auditPasswordResetCompleted = "password_reset.completed"
+	auditSsoIssued              = "sso.issued"
+	auditUsernameChanged        = "username.changed"
+	auditBanIssued              = "ban.issued"
+	auditBanLifted              = "ban.lifted"
+	auditDataExported           = "data.exported"
+	auditDeletionRequested      = "deletion.requested"
+	auditDeletionConfirmed      = "deletion.confirmed"
+	auditDeletionCancelled      = "deletion.cancelled"
+
+	auditHousekeepingSearch         = "housekeeping.search"
+	auditHousekeepingView           = "housekeeping.view"
+	auditHousekeepingUpdate         = "housekeeping.update"
+	auditHousekeepingPasswordReset  = "housekeeping.password_reset"
+	auditHousekeepingRevokeSessions = "housekeeping.revoke_sessions"
+	auditHousekeepingLinkedAccounts = "housekeeping.linked_accounts"
+
+	auditCurrencyAdjusted   = "currency.adjusted"
+	auditVoucherCreated     = "voucher.created"
+	auditBadgeAwarded       = "badge.awarded"
+	auditBadgeRevoked       = "badge.revoked"
+	auditArticlePublished   = "article.published"
+	auditArticleUnpublished = "article.unpublished"
+	auditCommentHidden      = "comment.hidden"
+	auditCommentUnhidden    = "comment.unhidden"
+	auditCommentDeleted     = "comment.deleted"
+)
+
+var securityActivityActions = []string{
+	auditLogin,
+	auditLoginFailed,
+	auditPasswordChanged,
+	auditEmailChanged,
+	auditPasswordResetRequested,
+	auditPasswordResetCompleted,
+	auditSsoIssued,
+	auditUsernameChanged,
+	auditDataExported,
+	auditDeletionRequested,
+	auditDeletionConfirmed,
+	auditDeletionCancelled,
+}
+
+func recordAudit(r *http.Request, actorId *int64, targetPlayerId *int64, action string, metadata map[string]interface{}) {
+	encoded := ""
+
+	if len(metadata) > 0 {
+		bytes, err := json.Marshal(metadata)
+
+		if err != nil {
+			log.Println("Failed to encode audit metadata:", err)
+		}
+
+		encoded = string(bytes)
+	}
+
+	userAgent := r.UserAgent()
+
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	event := AuditEvent{
+		ActorId:        actorId,
+		TargetPlayerId: targetPlayerId,
+		Action:         action,
+		Ip:             getUserIp(r),
+		UserAgent:      userAgent,
+		Metadata:       encoded,
+		CreatedAt:      time.Now().In(location),
+	}
+
+	if err := database.Create(&event).Error; err != nil {
+		log.Println("Failed to record audit event:", err)
+	}
+}
+
+func pruneAuditEvents() (int64, error) {
+	days := getEnvAsInt("AUDIT_RETENTION_DAYS", 365)
+
+	if days < 1 {
+		return 0, nil
+	}
+
+	cutoff := time.Now().In(location).AddDate(0, 0, -days)
+	result := database.Where("created_at < ?", cutoff).Delete(AuditEvent{})
+
+	return result.RowsAffected, result.Error
+}
+
+func startAuditPruner() {
+	interval := time.Duration(getEnvAsInt("AUDIT_PRUNE_INTERVAL_HOURS", 24)) * time.Hour
+
+	go func() {
+		for {
+			if _, err := pruneAuditEvents(); err != nil {
+				log.Println("Failed to prune audit events:", err)
+			}
+
+			time.Sleep(interval)
+		}
+	}()
+}
+
+func AuditEventsHandler(w http.ResponseWriter, r *http.Request) {
+	cursor, cursorError := decodeCursor(r.URL.Query().Get("cursor"))
+
+	if cursorError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid cursor"})
+		return
+	}
+
+	limit := parseLimit(r, 50, 200)
+	query := database.Model(AuditEvent{})
+	filters := r.URL.Query()
+
+	for _, column := range []string{"actor_id", "target_player_id"} {
+		if value := filters.Get(column); value != "" {
+			id, err := strconv.ParseInt(value, 10, 64)
+
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid " + column})
+				return
+			}
+
+			query = query.Where(column+" = ?", id)
+		}
+	}
+
+	if action := filters.Get("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+
+	if ip := filters.Get("ip"); ip != "" {
+		query = query.Where("ip = ?", ip)
+	}
+
+	for param, condition := range map[string]string{"from": "created_at >= ?", "to": "created_at < ?"} {
+		if value := filters.Get(param); value != "" {
+			bound, err := time.Parse(time.RFC3339, value)
+
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid " + param + " date"})
+				return
+			}
+
+			query = query.Where(condition, bound.In(location))
+		}
+	}
+
+	if cursor != nil {
+		query = query.Where("id < ?", cursor.ID)
+	}
+
+	var events []AuditEvent
+
+	var queryError = query.
+		Order("id DESC").
+		Limit(limit + 1).
+		Find(&events).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	nextCursor := ""
+
+	if len(events) > limit {
+		events = events[:limit]
+		nextCursor = encodeCursor(pageCursor{ID: events[len(events)-1].ID})
+	}
+
+	responses := make([]AuditEventResponse, 0, len(events))
+
+	for _, event := range events {
+		responses = append(responses, newAuditEventResponse(event))
+	}
+
+	encodePage(w, r, responses, nextCursor)
+}
+
+func SecurityActivityHandler(w http.ResponseWriter, r *http.Request) {
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	cursor, cursorError := decodeCursor(r.URL.Query().Get("cursor"))
+
+	if cursorError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid cursor"})
+		return
+	}
+
+	limit := parseLimit(r, 25, 100)
+
+	query := database.Model(AuditEvent{}).
+		Where("target_player_id = ?", player.ID).
+		Where("action IN (?)", securityActivityActions)
+
+	if cursor != nil {
+		query = query.Where("id < ?", cursor.ID)
+	}
+
+	var events []AuditEvent
+
+	var queryError = query.
+		Order("id DESC").
+		Limit(limit + 1).
+		Find(&events).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	nextCursor := ""
+
+	if len(events) > limit {
+		events = events[:limit]
+		nextCursor = encodeCursor(pageCursor{ID: events[len(events)-1].ID})
+	}
+
+	responses := make([]SecurityActivityResponse, 0, len(events))
+
+	for _, event := range events {
+		responses = append(responses, SecurityActivityResponse{
+			Action:    event.Action,
+			Ip:        event.Ip,
+			UserAgent: event.UserAgent,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+
+	encodePage(w, r, responses, nextCursor)
+}
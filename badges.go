@@ -0,0 +1,336 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"time"
+)
+
+var errBadgeNotFound = errors.New("badge not found")
+
+func applyBadgeVoucherReward(tx *gorm.DB, playerId int64, reward VoucherReward) error {
+	return awardBadge(tx, playerId, reward.BadgeCode)
+}
+
+func awardBadge(tx *gorm.DB, playerId int64, code string) error {
+	var badge Badge
+
+	var badgeError = tx.Model(Badge{}).
+		Where("code = ?", code).
+		First(&badge).
+		Error
+
+	if errors.Is(badgeError, gorm.ErrRecordNotFound) {
+		return errBadgeNotFound
+	}
+
+	if badgeError != nil {
+		return badgeError
+	}
+
+	var owned int
+
+	var ownedError = tx.Model(PlayerBadge{}).
+		Where("player_id = ?", playerId).
+		Where("badge_id = ?", badge.ID).
+		Count(&owned).
+		Error
+
+	if ownedError != nil || owned > 0 {
+		return ownedError
+	}
+
+	playerBadge := PlayerBadge{
+		PlayerId:  playerId,
+		BadgeId:   badge.ID,
+		CreatedAt: time.Now().In(location),
+	}
+
+	if err := tx.Set("gorm:save_associations", false).Create(&playerBadge).Error; err != nil && !isDuplicateKeyError(err) {
+		return err
+	}
+
+	return nil
+}
+
+func loadPlayerBadges(playerId int64) ([]PlayerBadge, error) {
+	var playerBadges []PlayerBadge
+
+	var queryError = database.Model(PlayerBadge{}).
+		Preload("Badge").
+		Where("player_id = ?", playerId).
+		Order("slot = 0, slot ASC").
+		Order("created_at DESC").
+		Find(&playerBadges).
+		Error
+
+	return playerBadges, queryError
+}
+
+func respondWithPlayerBadges(w http.ResponseWriter, r *http.Request, playerId int64) {
+	playerBadges, queryError := loadPlayerBadges(playerId)
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	responses := make([]PlayerBadgeResponse, 0, len(playerBadges))
+
+	for _, playerBadge := range playerBadges {
+		responses = append(responses, newPlayerBadgeResponse(playerBadge))
+	}
+
+	encodeResponse(w, r, responses)
+}
+
+func PlayerBadgesHandler(w http.ResponseWriter, r *http.Request) {
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	respondWithPlayerBadges(w, r, player.ID)
+}
+
+func EquipBadgesHandler(w http.ResponseWriter, r *http.Request) {
+	var req EquipBadgesRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	if len(req.Codes) > getEnvAsInt("BADGE_SLOTS", 5) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You've selected too many badges"})
+		return
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	playerBadges, queryError := loadPlayerBadges(player.ID)
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	owned := map[string]PlayerBadge{}
+
+	for _, playerBadge := range playerBadges {
+		owned[playerBadge.Badge.Code] = playerBadge
+	}
+
+	slots := map[int64]int{}
+
+	for i, code := range req.Codes {
+		playerBadge, exists := owned[code]
+
+		if !exists {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You don't own the badge " + code})
+			return
+		}
+
+		if _, duplicate := slots[playerBadge.ID]; duplicate {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You can only equip a badge once"})
+			return
+		}
+
+		slots[playerBadge.ID] = i + 1
+	}
+
+	transactionError := database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(PlayerBadge{}).Where("player_id = ?", player.ID).UpdateColumn("slot", 0).Error; err != nil {
+			return err
+		}
+
+		for playerBadgeId, slot := range slots {
+			if err := tx.Model(PlayerBadge{}).Where("id = ?", playerBadgeId).UpdateColumn("slot", slot).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if transactionError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: transactionError.Error()})
+		return
+	}
+
+	responseCache.delete(staffCacheKey)
+	respondWithPlayerBadges(w, r, player.ID)
+}
+
+func BadgesHandler(w http.ResponseWriter, r *http.Request) {
+	var badges []Badge
+
+	if err := database.Model(Badge{}).Order("code ASC").Find(&badges).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	responses := make([]BadgeResponse, 0, len(badges))
+
+	for _, badge := range badges {
+		responses = append(responses, newBadgeResponse(badge))
+	}
+
+	encodeResponse(w, r, responses)
+}
+
+func SaveBadgeHandler(w http.ResponseWriter, r *http.Request) {
+	var req BadgeRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	var badge Badge
+
+	var saveError = database.
+		Where(map[string]interface{}{"code": req.Code}).
+		Assign(map[string]interface{}{
+			"name":        req.Name,
+			"description": req.Description,
+			"image_path":  req.ImagePath,
+		}).
+		FirstOrCreate(&badge).
+		Error
+
+	if saveError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: saveError.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(newBadgeResponse(badge))
+}
+
+func AwardBadgeHandler(w http.ResponseWriter, r *http.Request) {
+	playerId, idError := parseIdParam(r, "id")
+
+	if idError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid player id"})
+		return
+	}
+
+	var req BadgeAwardRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	actor, actorError := getAuthenticatedPlayer(r)
+
+	if actorError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: actorError.Error()})
+		return
+	}
+
+	var player Player
+
+	if err := database.Model(Player{}).Where("id = ?", playerId).First(&player).Error; err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested player couldn't be found"})
+		return
+	}
+
+	awardError := awardBadge(database, player.ID, req.Code)
+
+	if errors.Is(awardError, errBadgeNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested badge couldn't be found"})
+		return
+	}
+
+	if awardError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: awardError.Error()})
+		return
+	}
+
+	recordAudit(r, &actor.ID, &player.ID, auditBadgeAwarded, map[string]interface{}{"code": req.Code})
+
+	respondWithPlayerBadges(w, r, player.ID)
+}
+
+func RevokeBadgeHandler(w http.ResponseWriter, r *http.Request) {
+	playerId, idError := parseIdParam(r, "id")
+
+	if idError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid player id"})
+		return
+	}
+
+	actor, actorError := getAuthenticatedPlayer(r)
+
+	if actorError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: actorError.Error()})
+		return
+	}
+
+	var deleteError = database.
+		Where("player_id = ?", playerId).
+		Where("badge_id IN (?)", database.Table("badges").Select("id").Where("code = ?", mux.Vars(r)["code"]).SubQuery()).
+		Delete(PlayerBadge{}).
+		Error
+
+	if deleteError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: deleteError.Error()})
+		return
+	}
+
+	recordAudit(r, &actor.ID, &playerId, auditBadgeRevoked, map[string]interface{}{"code": mux.Vars(r)["code"]})
+
+	responseCache.delete(staffCacheKey)
+	respondWithPlayerBadges(w, r, playerId)
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	banTypeAccount     = "account"
+	banTypeIp          = "ip"
+	banTypeMachine     = "machine"
+	banTypeEmailDomain = "email_domain"
+)
+
+func activeBans() *gorm.DB {
+	return database.Model(Ban{}).
+		Where("lifted_at IS NULL").
+		Where("is_permanent = ? OR expires_at > ?", true, time.Now().In(location))
+}
+
+func firstActiveBan(query *gorm.DB) (*Ban, error) {
+	var ban Ban
+
+	var queryError = query.
+		Order("is_permanent DESC").
+		Order("expires_at DESC").
+		First(&ban).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if queryError != nil {
+		return nil, queryError
+	}
+
+	return &ban, nil
+}
+
+func emailDomain(email string) string {
+	if at := strings.LastIndex(email, "@"); at != -1 {
+		return strings.ToLower(email[at+1:])
+	}
+
+	return ""
+}
+
+func getMachineId(r *http.Request) string {
+	machineId := strings.TrimSpace(r.Header.Get("X-Machine-Id"))
+
+	if len(machineId) > 255 {
+		return machineId[:255]
+	}
+
+	return machineId
+}
+
+func banConditions(ip string, machineId string, email string) (string, []interface{}) {
+	conditions := []string{"(type = ? AND value = ?)"}
+	values := []interface{}{banTypeIp, ip}
+
+	if machineId != "" {
+		conditions = append(conditions, "(type = ? AND value = ?)")
+		values = append(values, banTypeMachine, machineId)
+	}
+
+	if domain := emailDomain(email); domain != "" {
+		conditions = append(conditions, "(type = ? AND value = ?)")
+		values = append(values, banTypeEmailDomain, domain)
+	}
+
+	return strings.Join(conditions, " OR "), values
+}
+
+func findPlayerBan(player Player, ip string, machineId string) (*Ban, error) {
+	conditions, values := banConditions(ip, machineId, player.Email)
+
+	return firstActiveBan(activeBans().
+		Where("(type = ? AND player_id = ?) OR "+conditions, append([]interface{}{banTypeAccount, player.ID}, values...)...))
+}
+
+func findRegistrationBan(ip string, machineId string, email string) (*Ban, error) {
+	conditions, values := banConditions(ip, machineId, email)
+
+	return firstActiveBan(activeBans().Where(conditions, values...))
+}
+
+func writeBanned(w http.ResponseWriter, ban Ban) {
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(BannedResponse{
+		DefaultApiResponse: DefaultApiResponse{Message: "You have been banned"},
+		Ban:                newBanInfoResponse(ban),
+	})
+}
+
+func rejectBannedPlayer(w http.ResponseWriter, r *http.Request, player Player) bool {
+	ban, banError := findPlayerBan(player, getUserIp(r), getMachineId(r))
+
+	if banError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: banError.Error()})
+		return true
+	}
+
+	if ban != nil {
+		writeBanned(w, *ban)
+		return true
+	}
+
+	return false
+}
+
+func BansHandler(w http.ResponseWriter, r *http.Request) {
+	cursor, cursorError := decodeCursor(r.URL.Query().Get("cursor"))
+
+	if cursorError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid cursor"})
+		return
+	}
+
+	limit := parseLimit(r, 50, 200)
+	query := database.Model(Ban{})
+
+	if r.URL.Query().Get("active") == "true" {
+		query = activeBans()
+	}
+
+	if banType := r.URL.Query().Get("type"); banType != "" {
+		query = query.Where("type = ?", banType)
+	}
+
+	if value := r.URL.Query().Get("value"); value != "" {
+		query = query.Where("value = ?", value)
+	}
+
+	if cursor != nil {
+		query = query.Where("id < ?", cursor.ID)
+	}
+
+	var bans []Ban
+
+	var queryError = query.
+		Order("id DESC").
+		Limit(limit + 1).
+		Find(&bans).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	nextCursor := ""
+
+	if len(bans) > limit {
+		bans = bans[:limit]
+		nextCursor = encodeCursor(pageCursor{ID: bans[len(bans)-1].ID})
+	}
+
+	responses := make([]BanResponse, 0, len(bans))
+
+	for _, ban := range bans {
+		responses = append(responses, newBanResponse(ban))
+	}
+
+	encodePage(w, r, responses, nextCursor)
+}
+
+func IssueBanHandler(w http.ResponseWriter, r *http.Request) {
+	var req BanRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	req.Value = strings.TrimSpace(req.Value)
+
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	now := time.Now().In(location)
+
+	if !req.IsPermanent && (req.ExpiresAt == nil || !req.ExpiresAt.After(now)) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Temporary bans need an expiry in the future"})
+		return
+	}
+
+	issuer, issuerError := getAuthenticatedPlayer(r)
+
+	if issuerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: issuerError.Error()})
+		return
+	}
+
+	ban := Ban{
+		Type:        req.Type,
+		Value:       req.Value,
+		Reason:      req.Reason,
+		IssuerId:    issuer.ID,
+		IsPermanent: req.IsPermanent,
+		CreatedAt:   now,
+	}
+
+	if !req.IsPermanent {
+		ban.ExpiresAt = req.ExpiresAt
+	}
+
+	switch req.Type {
+	case banTypeAccount:
+		var player Player
+
+		var playerError = database.Model(Player{}).
+			Where("username = ?", req.Value).
+			First(&player).
+			Error
+
+		if errors.Is(playerError, gorm.ErrRecordNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested player couldn't be found"})
+			return
+		}
+
+		if playerError != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+			return
+		}
+
+		ban.Value = player.Username
+		ban.PlayerId = &player.ID
+	case banTypeEmailDomain:
+		ban.Value = strings.ToLower(strings.TrimPrefix(req.Value, "@"))
+	}
+
+	if err := database.Create(&ban).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	recordAudit(r, &issuer.ID, ban.PlayerId, auditBanIssued, map[string]interface{}{"ban_id": ban.ID, "type": ban.Type, "value": ban.Value})
+
+	json.NewEncoder(w).Encode(newBanResponse(ban))
+}
+
+func LiftBanHandler(w http.ResponseWriter, r *http.Request) {
+	banId, idError := parseIdParam(r, "id")
+
+	if idError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid ban id"})
+		return
+	}
+
+	actor, actorError := getAuthenticatedPlayer(r)
+
+	if actorError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: actorError.Error()})
+		return
+	}
+
+	var ban Ban
+
+	var queryError = database.Model(Ban{}).
+		Where("id = ?", banId).
+		First(&ban).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested ban couldn't be found"})
+		return
+	}
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	if ban.LiftedAt != nil {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "This ban has already been lifted"})
+		return
+	}
+
+	now := time.Now().In(location)
+	ban.LiftedAt = &now
+	ban.LiftedById = &actor.ID
+
+	var updateError = database.Model(&ban).
+		Updates(map[string]interface{}{
+			"lifted_at":    now,
+			"lifted_by_id": actor.ID,
+		}).
+		Error
+
+	if updateError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: updateError.Error()})
+		return
+	}
+
+	recordAudit(r, &actor.ID, ban.PlayerId, auditBanLifted, map[string]interface{}{"ban_id": ban.ID})
+
+	json.NewEncoder(w).Encode(newBanResponse(ban))
+}
@@ -47,6 +47,95 @@ func loadDatabase() {
 	}
 }
 
+func migrateDatabase() {
+	rolesHadVisibility := database.Dialect().HasColumn("roles", "is_hidden")
+	hadNameChanges := database.HasTable(&PlayerNameChange{})
+
+	if database.HasTable(&PlayerBadge{}) && !database.Dialect().HasIndex("player_badges", "idx_player_badge") {
+		log.Println("Removing duplicate player badges before adding the unique index")
+
+		var dedupeError = database.Exec("DELETE duplicate FROM player_badges duplicate " +
+			"JOIN player_badges original ON original.player_id = duplicate.player_id " +
+			"AND original.badge_id = duplicate.badge_id AND original.id < duplicate.id").Error
+
+		if dedupeError != nil {
+			log.Fatalln(dedupeError)
+		}
+	}
+
+	migrationError := database.AutoMigrate(
+		&Role{},
+		&PlayerPrivacySettings{},
+		&RolePermission{},
+		&HotelOnlinePeak{},
+		&HotelDailyLogins{},
+		&LeaderboardEntry{},
+		&CurrencyTransaction{},
+		&Voucher{},
+		&VoucherReward{},
+		&VoucherRedemption{},
+		&PlayerLoginStreak{},
+		&Article{},
+		&ArticleComment{},
+		&ArticleReaction{},
+		&Badge{},
+		&PlayerBadge{},
+		&GuestbookEntry{},
+		&Group{},
+		&GroupMember{},
+		&GroupJoinRequest{},
+		&Ban{},
+		&AuditEvent{},
+		&PlayerSessionRevocation{},
+		&PlayerNameChange{},
+		&AccountDeletionRequest{},
+		&RateLimitBucket{},
+		&RegistrationChallenge{},
+		&SchemaMigration{},
+	).Error
+
+	if migrationError != nil {
+		log.Fatalln(migrationError)
+	}
+
+	runOnce("player_id_tokens", func() error {
+		if hadNameChanges || !database.HasTable("oauth2_token") {
+			return nil
+		}
+
+		log.Println("Access tokens now reference player ids, revoking tokens issued by username")
+		return database.Exec("DELETE FROM oauth2_token").Error
+	})
+
+	runOnce("hotel_daily_logins", backfillDailyLogins)
+
+	if !rolesHadVisibility {
+		database.Model(Role{}).
+			Where("id = ?", getEnvAsInt64("DEFAULT_ROLE_ID", 1)).
+			Update("is_hidden", true)
+	}
+}
+
+func runOnce(name string, migration func() error) {
+	var count int
+
+	if err := database.Model(SchemaMigration{}).Where("name = ?", name).Count(&count).Error; err != nil {
+		log.Fatalln(err)
+	}
+
+	if count > 0 {
+		return
+	}
+
+	if err := migration(); err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := database.Create(&SchemaMigration{Name: name, RanAt: time.Now().In(location)}).Error; err != nil {
+		log.Fatalln(err)
+	}
+}
+
 func setupOauth() {
 	manager := manage.NewDefaultManager()
 
@@ -145,30 +234,31 @@ func authorizeMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		player, playerError := findTokenPlayer(tokenInfo)
+
+		if playerError != nil {
+			w.WriteHeader(401)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+			return
+		}
+
+		revoked, revokedError := isTokenRevoked(player.ID, tokenInfo)
+
+		if revokedError != nil || revoked {
+			w.WriteHeader(401)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Your session has expired, please log in again"})
+			return
+		}
+
+		if rejectBannedPlayer(w, r, player) {
+			return
+		}
+
 		ctx := context.WithValue(r.Context(), "tokenInfo", tokenInfo)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
 func serveHttp() {
-	log.Fatal(http.ListenAndServe("0.0.0.0:"+os.Getenv("HTTP_PORT"), corsHandler(router)))
-}
-
-func corsHandler(h http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "*")
-		w.Header().Set("Access-Control-Allow-Credentials", "false")
-		w.Header().Set("Access-Control-Allow-Headers", r.Header.Get("Access-Control-Request-Headers"))
-
-		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
-			w.Header().Set("Vary", "Origin, Access-Control-Request-Method, Access-Control-Request-Headers")
-			w.WriteHeader(http.StatusNoContent)
-		} else {
-			w.Header().Set("Vary", "Origin")
-			h.ServeHTTP(w, r)
-		}
-	})
+	log.Fatal(http.ListenAndServe("0.0.0.0:"+os.Getenv("HTTP_PORT"), corsHandler(clientIpMiddleware(router))))
 }
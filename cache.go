@@ -0,0 +1,91 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+type memoryCache struct {
+	mutex   sync.RWMutex
+	entries map[string]cacheEntry
+}
+
+var responseCache = newMemoryCache()
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{entries: map[string]cacheEntry{}}
+}
+
+func (c *memoryCache) get(key string) (interface{}, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	entry, exists := c.entries[key]
+
+	if !exists || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+func (c *memoryCache) set(key string, value interface{}, ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries[key] = cacheEntry{value: value, expiresAt: time.Now().Add(ttl)}
+}
+
+func (c *memoryCache) delete(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.entries, key)
+}
+
+func (c *memoryCache) remember(key string, ttl time.Duration, load func() (interface{}, error)) (interface{}, error) {
+	if value, exists := c.get(key); exists {
+		return value, nil
+	}
+
+	value, err := load()
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.set(key, value, ttl)
+	return value, nil
+}
+
+func (c *memoryCache) take(key string) (interface{}, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, exists := c.entries[key]
+	delete(c.entries, key)
+
+	if !exists || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+
+	return entry.value, true
+}
+
+func (c *memoryCache) purgeExpired() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+
+	for key, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+var trustedProxiesOnce sync.Once
+var trustedProxies []*net.IPNet
+
+func loadTrustedProxies() {
+	for _, entry := range strings.Split(getEnv("TRUSTED_PROXIES", "127.0.0.1/32,::1/128"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+
+		if err != nil {
+			log.Println("Ignoring invalid trusted proxy", entry)
+			continue
+		}
+
+		trustedProxies = append(trustedProxies, network)
+	}
+}
+
+func isTrustedProxy(ip string) bool {
+	trustedProxiesOnce.Do(loadTrustedProxies)
+
+	parsed := net.ParseIP(ip)
+
+	if parsed == nil {
+		return false
+	}
+
+	for _, network := range trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalizeIp(address string) string {
+	address = strings.Trim(strings.TrimSpace(address), "\"")
+
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		address = host
+	}
+
+	address = strings.TrimSuffix(strings.TrimPrefix(address, "["), "]")
+
+	if zone := strings.Index(address, "%"); zone != -1 {
+		address = address[:zone]
+	}
+
+	ip := net.ParseIP(address)
+
+	if ip == nil {
+		return ""
+	}
+
+	if v4 := ip.To4(); v4 != nil {
+		return v4.String()
+	}
+
+	return ip.String()
+}
+
+func forwardedChain(r *http.Request) []string {
+	var chain []string
+
+	switch header := strings.ToLower(getEnv("TRUSTED_PROXY_HEADER", "x-forwarded-for")); header {
+	case "forwarded":
+		for _, element := range strings.Split(strings.Join(r.Header.Values("Forwarded"), ","), ",") {
+			for _, pair := range strings.Split(element, ";") {
+				if key, value, found := strings.Cut(strings.TrimSpace(pair), "="); found && strings.EqualFold(key, "for") {
+					chain = append(chain, value)
+				}
+			}
+		}
+	case "x-real-ip":
+		if realIp := r.Header.Get("X-Real-Ip"); realIp != "" {
+			chain = append(chain, realIp)
+		}
+	case "x-forwarded-for":
+		for _, hop := range strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				chain = append(chain, hop)
+			}
+		}
+	default:
+		log.Println("Ignoring unknown trusted proxy header", header)
+	}
+
+	return chain
+}
+
+func resolveClientIp(r *http.Request) string {
+	clientIp := normalizeIp(r.RemoteAddr)
+
+	if clientIp == "" {
+		return r.RemoteAddr
+	}
+
+	if !isTrustedProxy(clientIp) {
+		return clientIp
+	}
+
+	chain := forwardedChain(r)
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		hop := normalizeIp(chain[i])
+
+		if hop == "" {
+			break
+		}
+
+		clientIp = hop
+
+		if !isTrustedProxy(hop) {
+			break
+		}
+	}
+
+	return clientIp
+}
+
+func clientIpMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), "clientIp", resolveClientIp(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
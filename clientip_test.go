@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+)
+
+func useTrustedProxies(t *testing.T, cidrs ...string) {
+	trustedProxiesOnce.Do(func() {})
+	previous := trustedProxies
+	trustedProxies = nil
+
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		trustedProxies = append(trustedProxies, network)
+	}
+
+	t.Cleanup(func() { trustedProxies = previous })
+}
+
+func TestNormalizeIp(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"1.2.3.4", "1.2.3.4"},
+		{" 1.2.3.4 ", "1.2.3.4"},
+		{"1.2.3.4:5678", "1.2.3.4"},
+		{"[2001:DB8::1]:443", "2001:db8::1"},
+		{"[2001:db8::1]", "2001:db8::1"},
+		{"2001:0db8:0000:0000:0000:0000:0000:0001", "2001:db8::1"},
+		{"fe80::1%eth0", "fe80::1"},
+		{"[fe80::1%eth0]:80", "fe80::1"},
+		{"::ffff:8.8.8.8", "8.8.8.8"},
+		{"\"[::1]:80\"", "::1"},
+		{"unknown", ""},
+		{"_hidden", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if got := normalizeIp(test.address); got != test.want {
+			t.Errorf("normalizeIp(%q) = %q, want %q", test.address, got, test.want)
+		}
+	}
+}
+
+func TestResolveClientIp(t *testing.T) {
+	useTrustedProxies(t, "10.0.0.0/8", "::1/128")
+
+	tests := []struct {
+		name       string
+		header     string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{"untrusted peer ignores headers", "x-forwarded-for", "1.2.3.4:5555", map[string]string{"X-Forwarded-For": "9.9.9.9"}, "1.2.3.4"},
+		{"trusted peer without headers", "x-forwarded-for", "10.0.0.1:80", nil, "10.0.0.1"},
+		{"rightmost untrusted hop wins", "x-forwarded-for", "10.0.0.1:80", map[string]string{"X-Forwarded-For": "6.6.6.6, 7.7.7.7, 10.0.0.2"}, "7.7.7.7"},
+		{"all hops trusted", "x-forwarded-for", "10.0.0.1:80", map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"invalid hop stops the walk", "x-forwarded-for", "10.0.0.1:80", map[string]string{"X-Forwarded-For": "6.6.6.6, garbage, 10.0.0.2"}, "10.0.0.2"},
+		{"v4-mapped hop", "x-forwarded-for", "10.0.0.1:80", map[string]string{"X-Forwarded-For": "::ffff:8.8.8.8"}, "8.8.8.8"},
+		{"forwarded ignored when not configured", "x-forwarded-for", "10.0.0.1:80", map[string]string{"Forwarded": "for=1.2.3.4"}, "10.0.0.1"},
+		{"x-real-ip ignored when not configured", "x-forwarded-for", "10.0.0.1:80", map[string]string{"X-Real-Ip": "1.2.3.4"}, "10.0.0.1"},
+		{"forwarded header", "forwarded", "[::1]:80", map[string]string{"Forwarded": "for=\"[2001:DB8::1]:4711\";proto=https, for=10.1.1.1"}, "2001:db8::1"},
+		{"forwarded obfuscated hop", "forwarded", "10.0.0.1:80", map[string]string{"Forwarded": "for=unknown"}, "10.0.0.1"},
+		{"x-forwarded-for ignored in forwarded mode", "forwarded", "10.0.0.1:80", map[string]string{"X-Forwarded-For": "1.2.3.4"}, "10.0.0.1"},
+		{"x-real-ip header", "x-real-ip", "10.0.0.1:80", map[string]string{"X-Real-Ip": "1.2.3.4"}, "1.2.3.4"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("TRUSTED_PROXY_HEADER", test.header)
+
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = test.remoteAddr
+
+			for name, value := range test.headers {
+				r.Header.Set(name, value)
+			}
+
+			if got := resolveClientIp(r); got != test.want {
+				t.Errorf("resolveClientIp() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"log"
+)
+
+func runCommand(name string, args []string) {
+	switch name {
+	case "reconcile-currency":
+		flags := flag.NewFlagSet(name, flag.ExitOnError)
+		baseline := flags.Bool("baseline", false, "record opening balances for players without ledger history")
+		flags.Parse(args)
+
+		mismatches, err := reconcileCurrency(*baseline)
+
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if mismatches > 0 {
+			log.Fatalf("Found %d currency balances that don't match the ledger", mismatches)
+		}
+
+		log.Println("All currency balances match the ledger")
+	case "prune-audit":
+		pruned, err := pruneAuditEvents()
+
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		log.Printf("Pruned %d audit events", pruned)
+	case "process-deletions":
+		processed, err := processAccountDeletions()
+
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		log.Printf("Anonymized %d deleted accounts", processed)
+	default:
+		log.Fatalln("Unknown command", name)
+	}
+}
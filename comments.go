@@ -0,0 +1,675 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type reactionCountRow struct {
+	CommentId int64
+	Type      string
+	Count     int64
+}
+
+func findPublishedArticle(w http.ResponseWriter, r *http.Request) (Article, bool) {
+	var article Article
+
+	var queryError = publishedArticles().
+		Where("slug = ?", mux.Vars(r)["slug"]).
+		First(&article).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested article couldn't be found"})
+		return article, false
+	}
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return article, false
+	}
+
+	return article, true
+}
+
+func findCommentParam(w http.ResponseWriter, r *http.Request) (ArticleComment, bool) {
+	var comment ArticleComment
+
+	commentId, idError := parseIdParam(r, "id")
+
+	if idError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid comment id"})
+		return comment, false
+	}
+
+	var queryError = database.Model(ArticleComment{}).
+		Where("id = ?", commentId).
+		First(&comment).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested comment couldn't be found"})
+		return comment, false
+	}
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return comment, false
+	}
+
+	return comment, true
+}
+
+func findVisibleCommentParam(w http.ResponseWriter, r *http.Request) (ArticleComment, bool) {
+	comment, found := findCommentParam(w, r)
+
+	if !found || !comment.IsHidden {
+		return comment, found
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return comment, false
+	}
+
+	allowed, permissionError := playerHasPermission(player.ID, permissionModerateComments)
+
+	if permissionError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: permissionError.Error()})
+		return comment, false
+	}
+
+	if !allowed {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested comment couldn't be found"})
+		return comment, false
+	}
+
+	return comment, true
+}
+
+func isAllowedReaction(reactionType string) bool {
+	for _, allowed := range strings.Split(getEnv("ALLOWED_REACTIONS", "like"), ",") {
+		if strings.TrimSpace(allowed) == reactionType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func loadReactionCounts(articleId int64, commentIds []int64) (map[int64]map[string]int64, error) {
+	var rows []reactionCountRow
+
+	var queryError = database.Table("article_reactions").
+		Select("comment_id, type, COUNT(*) AS count").
+		Where("article_id = ?", articleId).
+		Where("comment_id IN (?)", commentIds).
+		Group("comment_id, type").
+		Scan(&rows).
+		Error
+
+	counts := map[int64]map[string]int64{}
+
+	for _, row := range rows {
+		if counts[row.CommentId] == nil {
+			counts[row.CommentId] = map[string]int64{}
+		}
+
+		counts[row.CommentId][row.Type] = row.Count
+	}
+
+	return counts, queryError
+}
+
+func newCommentResponse(comment ArticleComment, reactions map[int64]map[string]int64) CommentResponse {
+	counts := reactions[comment.ID]
+
+	if counts == nil {
+		counts = map[string]int64{}
+	}
+
+	return CommentResponse{
+		ID:        comment.ID,
+		ParentId:  comment.ParentId,
+		Author:    newPlayerCardResponse(comment.Player),
+		Body:      comment.Body,
+		IsHidden:  comment.IsHidden,
+		Reactions: counts,
+		CreatedAt: comment.CreatedAt,
+		EditedAt:  comment.EditedAt,
+	}
+}
+
+func ArticleCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	article, found := findPublishedArticle(w, r)
+
+	if !found {
+		return
+	}
+
+	cursor, cursorError := decodeCursor(r.URL.Query().Get("cursor"))
+
+	if cursorError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid cursor"})
+		return
+	}
+
+	limit := parseLimit(r, 20, 100)
+
+	query := database.Model(ArticleComment{}).
+		Preload("Player").
+		Preload("Player.Data").
+		Preload("Player.AvatarData").
+		Preload("Player.PrivacySettings").
+		Where("article_id = ?", article.ID).
+		Where("parent_id IS NULL").
+		Where("is_hidden = ?", false)
+
+	if cursor != nil {
+		query = query.Where("id < ?", cursor.ID)
+	}
+
+	var comments []ArticleComment
+
+	var queryError = query.
+		Order("id DESC").
+		Limit(limit + 1).
+		Find(&comments).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	nextCursor := ""
+
+	if len(comments) > limit {
+		comments = comments[:limit]
+		nextCursor = encodeCursor(pageCursor{ID: comments[len(comments)-1].ID})
+	}
+
+	parentIds := make([]int64, 0, len(comments))
+
+	for _, comment := range comments {
+		parentIds = append(parentIds, comment.ID)
+	}
+
+	var replies []ArticleComment
+
+	if len(parentIds) > 0 {
+		var repliesError = database.Model(ArticleComment{}).
+			Preload("Player").
+			Preload("Player.Data").
+			Preload("Player.AvatarData").
+			Preload("Player.PrivacySettings").
+			Where("parent_id IN (?)", parentIds).
+			Where("is_hidden = ?", false).
+			Order("id ASC").
+			Find(&replies).
+			Error
+
+		if repliesError != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: repliesError.Error()})
+			return
+		}
+	}
+
+	commentIds := append([]int64{}, parentIds...)
+
+	for _, reply := range replies {
+		commentIds = append(commentIds, reply.ID)
+	}
+
+	reactions := map[int64]map[string]int64{}
+
+	if len(commentIds) > 0 {
+		var reactionsError error
+		reactions, reactionsError = loadReactionCounts(article.ID, commentIds)
+
+		if reactionsError != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: reactionsError.Error()})
+			return
+		}
+	}
+
+	repliesByParent := map[int64][]CommentResponse{}
+
+	for _, reply := range replies {
+		repliesByParent[*reply.ParentId] = append(repliesByParent[*reply.ParentId], newCommentResponse(reply, reactions))
+	}
+
+	responses := make([]CommentResponse, 0, len(comments))
+
+	for _, comment := range comments {
+		response := newCommentResponse(comment, reactions)
+		response.Replies = repliesByParent[comment.ID]
+		responses = append(responses, response)
+	}
+
+	encodePage(w, r, responses, nextCursor)
+}
+
+func CreateArticleCommentHandler(w http.ResponseWriter, r *http.Request) {
+	article, found := findPublishedArticle(w, r)
+
+	if !found {
+		return
+	}
+
+	var req CommentRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	req.Body = strings.TrimSpace(req.Body)
+
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	var recent int
+	window := time.Duration(getEnvAsInt("COMMENT_RATE_WINDOW_SECONDS", 60)) * time.Second
+
+	var recentError = database.Model(ArticleComment{}).
+		Unscoped().
+		Where("player_id = ?", player.ID).
+		Where("created_at > ?", time.Now().In(location).Add(-window)).
+		Count(&recent).
+		Error
+
+	if recentError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: recentError.Error()})
+		return
+	}
+
+	if recent >= getEnvAsInt("COMMENT_RATE_LIMIT", 3) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You're doing too much, slow down!"})
+		return
+	}
+
+	if req.ParentId != nil {
+		moderator, permissionError := playerHasPermission(player.ID, permissionModerateComments)
+
+		if permissionError != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: permissionError.Error()})
+			return
+		}
+
+		parentQuery := database.Model(ArticleComment{}).
+			Where("id = ?", *req.ParentId).
+			Where("article_id = ?", article.ID).
+			Where("parent_id IS NULL")
+
+		if !moderator {
+			parentQuery = parentQuery.Where("is_hidden = ?", false)
+		}
+
+		var parent ArticleComment
+
+		var parentError = parentQuery.
+			First(&parent).
+			Error
+
+		if errors.Is(parentError, gorm.ErrRecordNotFound) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You can only reply to top level comments"})
+			return
+		}
+
+		if parentError != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: parentError.Error()})
+			return
+		}
+	}
+
+	comment := ArticleComment{
+		ArticleId: article.ID,
+		PlayerId:  player.ID,
+		ParentId:  req.ParentId,
+		Body:      filterWords(req.Body),
+		CreatedAt: time.Now().In(location),
+	}
+
+	if err := database.Set("gorm:save_associations", false).Create(&comment).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	respondWithComment(w, r, comment.ID)
+}
+
+func UpdateCommentHandler(w http.ResponseWriter, r *http.Request) {
+	comment, found := findVisibleCommentParam(w, r)
+
+	if !found {
+		return
+	}
+
+	var req CommentRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	req.Body = strings.TrimSpace(req.Body)
+
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	if comment.PlayerId != player.ID {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You can only edit your own comments"})
+		return
+	}
+
+	var updateError = database.Model(&comment).
+		Updates(map[string]interface{}{
+			"body":      filterWords(req.Body),
+			"edited_at": time.Now().In(location),
+		}).
+		Error
+
+	if updateError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: updateError.Error()})
+		return
+	}
+
+	respondWithComment(w, r, comment.ID)
+}
+
+func DeleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	comment, found := findCommentParam(w, r)
+
+	if !found {
+		return
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	if comment.PlayerId != player.ID {
+		allowed, permissionError := playerHasPermission(player.ID, permissionModerateComments)
+
+		if permissionError != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: permissionError.Error()})
+			return
+		}
+
+		if !allowed {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You can only delete your own comments"})
+			return
+		}
+	}
+
+	deleteError := database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("parent_id = ?", comment.ID).Delete(ArticleComment{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&comment).Error
+	})
+
+	if deleteError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: deleteError.Error()})
+		return
+	}
+
+	if comment.PlayerId != player.ID {
+		recordAudit(r, &player.ID, &comment.PlayerId, auditCommentDeleted, map[string]interface{}{"comment_id": comment.ID})
+	}
+
+	json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The comment has been deleted"})
+}
+
+func HideCommentHandler(w http.ResponseWriter, r *http.Request) {
+	setCommentHidden(w, r, true)
+}
+
+func UnhideCommentHandler(w http.ResponseWriter, r *http.Request) {
+	setCommentHidden(w, r, false)
+}
+
+func setCommentHidden(w http.ResponseWriter, r *http.Request, hidden bool) {
+	comment, found := findCommentParam(w, r)
+
+	if !found {
+		return
+	}
+
+	actor, actorError := getAuthenticatedPlayer(r)
+
+	if actorError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: actorError.Error()})
+		return
+	}
+
+	if err := database.Model(&comment).Update("is_hidden", hidden).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	action := auditCommentUnhidden
+
+	if hidden {
+		action = auditCommentHidden
+	}
+
+	recordAudit(r, &actor.ID, &comment.PlayerId, action, map[string]interface{}{"comment_id": comment.ID})
+
+	respondWithComment(w, r, comment.ID)
+}
+
+func respondWithComment(w http.ResponseWriter, r *http.Request, commentId int64) {
+	var comment ArticleComment
+
+	var queryError = database.Model(ArticleComment{}).
+		Preload("Player").
+		Preload("Player.Data").
+		Preload("Player.AvatarData").
+		Preload("Player.PrivacySettings").
+		Where("id = ?", commentId).
+		First(&comment).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	reactions, reactionsError := loadReactionCounts(comment.ArticleId, []int64{comment.ID})
+
+	if reactionsError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: reactionsError.Error()})
+		return
+	}
+
+	encodeResponse(w, r, newCommentResponse(comment, reactions))
+}
+
+func ReactToArticleHandler(w http.ResponseWriter, r *http.Request) {
+	article, found := findPublishedArticle(w, r)
+
+	if !found {
+		return
+	}
+
+	saveReaction(w, r, article.ID, 0)
+}
+
+func RemoveArticleReactionHandler(w http.ResponseWriter, r *http.Request) {
+	article, found := findPublishedArticle(w, r)
+
+	if !found {
+		return
+	}
+
+	removeReaction(w, r, article.ID, 0)
+}
+
+func ReactToCommentHandler(w http.ResponseWriter, r *http.Request) {
+	comment, found := findVisibleCommentParam(w, r)
+
+	if !found {
+		return
+	}
+
+	saveReaction(w, r, comment.ArticleId, comment.ID)
+}
+
+func RemoveCommentReactionHandler(w http.ResponseWriter, r *http.Request) {
+	comment, found := findCommentParam(w, r)
+
+	if !found {
+		return
+	}
+
+	removeReaction(w, r, comment.ArticleId, comment.ID)
+}
+
+func saveReaction(w http.ResponseWriter, r *http.Request, articleId int64, commentId int64) {
+	var req ReactionRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil || !isAllowedReaction(req.Type) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Unknown reaction"})
+		return
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	var reaction ArticleReaction
+
+	var reactionError = database.
+		Where(map[string]interface{}{"article_id": articleId, "comment_id": commentId, "player_id": player.ID}).
+		Assign(ArticleReaction{Type: req.Type, CreatedAt: time.Now().In(location)}).
+		FirstOrCreate(&reaction).
+		Error
+
+	if reactionError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: reactionError.Error()})
+		return
+	}
+
+	respondWithReactions(w, r, articleId, commentId)
+}
+
+func removeReaction(w http.ResponseWriter, r *http.Request, articleId int64, commentId int64) {
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	var deleteError = database.
+		Where("article_id = ?", articleId).
+		Where("comment_id = ?", commentId).
+		Where("player_id = ?", player.ID).
+		Delete(ArticleReaction{}).
+		Error
+
+	if deleteError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: deleteError.Error()})
+		return
+	}
+
+	respondWithReactions(w, r, articleId, commentId)
+}
+
+func respondWithReactions(w http.ResponseWriter, r *http.Request, articleId int64, commentId int64) {
+	counts, countError := loadReactionCounts(articleId, []int64{commentId})
+
+	if countError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: countError.Error()})
+		return
+	}
+
+	reactions := counts[commentId]
+
+	if reactions == nil {
+		reactions = map[string]int64{}
+	}
+
+	encodeResponse(w, r, ReactionsResponse{Reactions: reactions})
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"github.com/gorilla/mux"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var corsMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+const corsOriginsCacheKey = "cors_origins"
+
+func normalizeOrigin(origin string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(origin)), "/")
+}
+
+func addCorsOrigin(origins map[string]bool, origin string) {
+	if origin = normalizeOrigin(origin); origin == "" {
+		return
+	}
+
+	if strings.Contains(origin, "://") {
+		origins[origin] = true
+		return
+	}
+
+	origins["https://"+origin] = true
+	origins["http://"+origin] = true
+}
+
+func loadCorsOrigins() (map[string]bool, error) {
+	origins := map[string]bool{}
+
+	if configured := os.Getenv("CORS_ALLOWED_ORIGINS"); configured != "" {
+		for _, origin := range strings.Split(configured, ",") {
+			addCorsOrigin(origins, origin)
+		}
+
+		return origins, nil
+	}
+
+	addCorsOrigin(origins, os.Getenv("SITE_URL"))
+
+	var clients []OauthClient
+
+	if err := database.Model(OauthClient{}).Find(&clients).Error; err != nil {
+		return origins, err
+	}
+
+	for _, client := range clients {
+		addCorsOrigin(origins, client.Domain)
+	}
+
+	return origins, nil
+}
+
+func isAllowedOrigin(origin string) bool {
+	ttl := time.Duration(getEnvAsInt("CORS_ORIGINS_CACHE_SECONDS", 60)) * time.Second
+
+	origins, err := responseCache.remember(corsOriginsCacheKey, ttl, func() (interface{}, error) {
+		return loadCorsOrigins()
+	})
+
+	if err != nil {
+		log.Println("Failed to load oauth client origins:", err)
+
+		fallback := map[string]bool{}
+		addCorsOrigin(fallback, os.Getenv("SITE_URL"))
+		origins = fallback
+	}
+
+	return origins.(map[string]bool)[normalizeOrigin(origin)]
+}
+
+func routeMethods(r *http.Request) []string {
+	var methods []string
+
+	for _, method := range corsMethods {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+
+		var match mux.RouteMatch
+
+		if router.Match(probe, &match) && match.MatchErr == nil {
+			methods = append(methods, method)
+		}
+	}
+
+	return methods
+}
+
+func corsHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Add("Vary", "Origin")
+
+		origin := r.Header.Get("Origin")
+		allowed := origin != "" && isAllowedOrigin(origin)
+
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Expose-Headers", "Location, Content-Disposition, Retry-After, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset")
+
+			if getEnv("CORS_ALLOW_CREDENTIALS", "true") == "true" {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+		if !allowed {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		methods := routeMethods(r)
+
+		if len(methods) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+		w.Header().Set("Access-Control-Allow-Headers", getEnv("CORS_ALLOWED_HEADERS", "Authorization, Content-Type, X-Machine-Id"))
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(getEnvAsInt("CORS_MAX_AGE_SECONDS", 600)))
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
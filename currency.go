@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/jinzhu/gorm"
+	"log"
+	"net/http"
+	"time"
+)
+
+var currencyColumns = map[string]string{
+	"credits":  "credit_balance",
+	"pixels":   "pixel_balance",
+	"seasonal": "seasonal_balance",
+	"gotw":     "gotw_points",
+}
+
+var errUnknownCurrency = errors.New("unknown currency")
+var errInsufficientBalance = errors.New("insufficient balance")
+
+type currencyMismatchRow struct {
+	PlayerId int64
+	Balance  int64
+	Ledger   int64
+}
+
+func adjustCurrency(tx *gorm.DB, playerId int64, currency string, amount int64, reason string, actorId *int64) (CurrencyTransaction, error) {
+	column, exists := currencyColumns[currency]
+
+	if !exists {
+		return CurrencyTransaction{}, errUnknownCurrency
+	}
+
+	var playerData PlayerData
+
+	var lockError = tx.Set("gorm:query_option", "FOR UPDATE").
+		Model(PlayerData{}).
+		Where("player_id = ?", playerId).
+		First(&playerData).
+		Error
+
+	if lockError != nil {
+		return CurrencyTransaction{}, lockError
+	}
+
+	balance := map[string]int64{
+		"credits":  playerData.CreditBalance,
+		"pixels":   playerData.PixelBalance,
+		"seasonal": playerData.SeasonalBalance,
+		"gotw":     playerData.GotwPoints,
+	}[currency] + amount
+
+	if balance < 0 {
+		return CurrencyTransaction{}, errInsufficientBalance
+	}
+
+	if err := tx.Model(&playerData).UpdateColumn(column, balance).Error; err != nil {
+		return CurrencyTransaction{}, err
+	}
+
+	return recordCurrencyTransaction(tx, playerId, currency, amount, balance, reason, actorId)
+}
+
+func recordCurrencyTransaction(tx *gorm.DB, playerId int64, currency string, amount int64, balanceAfter int64, reason string, actorId *int64) (CurrencyTransaction, error) {
+	transaction := CurrencyTransaction{
+		PlayerId:     playerId,
+		Currency:     currency,
+		Amount:       amount,
+		BalanceAfter: balanceAfter,
+		Reason:       reason,
+		ActorId:      actorId,
+		CreatedAt:    time.Now().In(location),
+	}
+
+	return transaction, tx.Create(&transaction).Error
+}
+
+func AdjustCurrencyHandler(w http.ResponseWriter, r *http.Request) {
+	playerId, idError := parseIdParam(r, "id")
+
+	if idError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid player id"})
+		return
+	}
+
+	var req CurrencyAdjustRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	actor, actorError := getAuthenticatedPlayer(r)
+
+	if actorError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: actorError.Error()})
+		return
+	}
+
+	var transaction CurrencyTransaction
+
+	transactionError := database.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transaction, err = adjustCurrency(tx, playerId, req.Currency, req.Amount, req.Reason, &actor.ID)
+		return err
+	})
+
+	if errors.Is(transactionError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested player couldn't be found"})
+		return
+	}
+
+	if errors.Is(transactionError, errInsufficientBalance) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "This would leave the player with a negative balance"})
+		return
+	}
+
+	if transactionError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: transactionError.Error()})
+		return
+	}
+
+	recordAudit(r, &actor.ID, &playerId, auditCurrencyAdjusted, map[string]interface{}{
+		"transaction_id": transaction.ID,
+		"currency":       req.Currency,
+		"amount":         req.Amount,
+		"reason":         req.Reason,
+	})
+
+	json.NewEncoder(w).Encode(newCurrencyTransactionResponse(transaction))
+}
+
+func CurrencyTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	cursor, cursorError := decodeCursor(r.URL.Query().Get("cursor"))
+
+	if cursorError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid cursor"})
+		return
+	}
+
+	limit := parseLimit(r, 25, 100)
+
+	query := database.Model(CurrencyTransaction{}).
+		Where("player_id = ?", player.ID)
+
+	if currency := r.URL.Query().Get("currency"); currency != "" {
+		query = query.Where("currency = ?", currency)
+	}
+
+	if cursor != nil {
+		query = query.Where("id < ?", cursor.ID)
+	}
+
+	var transactions []CurrencyTransaction
+
+	var queryError = query.
+		Order("id DESC").
+		Limit(limit + 1).
+		Find(&transactions).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	nextCursor := ""
+
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		nextCursor = encodeCursor(pageCursor{ID: transactions[len(transactions)-1].ID})
+	}
+
+	responses := make([]CurrencyTransactionResponse, 0, len(transactions))
+
+	for _, transaction := range transactions {
+		responses = append(responses, newCurrencyTransactionResponse(transaction))
+	}
+
+	encodePage(w, r, responses, nextCursor)
+}
+
+func reconcileCurrency(baseline bool) (int, error) {
+	mismatches := 0
+
+	for currency, column := range currencyColumns {
+		var rows []currencyMismatchRow
+
+		var queryError = database.Table("player_data").
+			Select(fmt.Sprintf("player_data.player_id, player_data.%s AS balance, "+
+				"COALESCE(SUM(currency_transactions.amount), 0) AS ledger", column)).
+			Joins("LEFT JOIN currency_transactions ON currency_transactions.player_id = player_data.player_id "+
+				"AND currency_transactions.currency = ?", currency).
+			Group(fmt.Sprintf("player_data.player_id, player_data.%s", column)).
+			Having("balance <> ledger").
+			Scan(&rows).
+			Error
+
+		if queryError != nil {
+			return mismatches, queryError
+		}
+
+		for _, row := range rows {
+			if baseline && row.Ledger == 0 {
+				_, err := recordCurrencyTransaction(database, row.PlayerId, currency, row.Balance, row.Balance, "opening balance", nil)
+
+				if err != nil {
+					return mismatches, err
+				}
+
+				continue
+			}
+
+			mismatches++
+			log.Printf("Player %d %s balance is %d but the ledger totals %d", row.PlayerId, currency, row.Balance, row.Ledger)
+		}
+	}
+
+	return mismatches, nil
+}
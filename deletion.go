@@ -0,0 +1,375 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+	"golang.org/x/crypto/bcrypt"
+	"log"
+	"net/http"
+	"time"
+)
+
+func findPendingDeletion(playerId int64) (*AccountDeletionRequest, error) {
+	var request AccountDeletionRequest
+
+	var queryError = database.Model(AccountDeletionRequest{}).
+		Where("player_id = ?", playerId).
+		Where("cancelled_at IS NULL").
+		Where("completed_at IS NULL").
+		Order("id DESC").
+		First(&request).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	if queryError != nil {
+		return nil, queryError
+	}
+
+	return &request, nil
+}
+
+func cancelDeletionOnLogin(r *http.Request, player Player) error {
+	request, requestError := findPendingDeletion(player.ID)
+
+	if requestError != nil || request == nil {
+		return requestError
+	}
+
+	if err := database.Model(request).Update("cancelled_at", time.Now().In(location)).Error; err != nil {
+		return err
+	}
+
+	recordAudit(r, &player.ID, &player.ID, auditDeletionCancelled, map[string]interface{}{"reason": "login"})
+
+	return nil
+}
+
+func anonymizePlayer(tx *gorm.DB, playerId int64) error {
+	username := fmt.Sprintf("deleted_%d", playerId)
+
+	seedRandom()
+
+	unusable, hashError := bcrypt.GenerateFromPassword([]byte(randSeq(64)), bcrypt.DefaultCost)
+
+	if hashError != nil {
+		return hashError
+	}
+
+	if err := tx.Model(Player{}).Where("id = ?", playerId).Updates(map[string]interface{}{
+		"username": username,
+		"email":    username + "@invalid",
+		"password": string(unusable),
+	}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(PlayerAvatarData{}).Where("player_id = ?", playerId).Updates(map[string]interface{}{
+		"motto":       "",
+		"figure_code": "",
+	}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(PlayerWebsiteData{}).Where("player_id = ?", playerId).Updates(map[string]interface{}{
+		"initial_ip": "",
+		"last_ip":    "",
+	}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(AuditEvent{}).Where("target_player_id = ? OR actor_id = ?", playerId, playerId).Updates(map[string]interface{}{
+		"ip":         "",
+		"user_agent": "",
+		"metadata":   "",
+	}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(VoucherRedemption{}).Where("player_id = ?", playerId).Update("ip", "").Error; err != nil {
+		return err
+	}
+
+	if err := tx.Model(Ban{}).Where("type = ?", banTypeAccount).Where("player_id = ?", playerId).Update("value", username).Error; err != nil {
+		return err
+	}
+
+	// Replies from other players stay in their threads, so comments are blanked rather than removed.
+	if err := tx.Unscoped().Model(ArticleComment{}).Where("player_id = ?", playerId).Updates(map[string]interface{}{
+		"body":       "",
+		"deleted_at": time.Now().In(location),
+	}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Unscoped().Where("author_id = ? OR profile_player_id = ?", playerId, playerId).Delete(GuestbookEntry{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("player_id = ?", playerId).Delete(PlayerSsoToken{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("player_id = ?", playerId).Delete(PlayerPasswordResetLink{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("player_id = ?", playerId).Delete(PlayerPrivacySettings{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("player_id = ?", playerId).Delete(PlayerNameChange{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("origin_player_id = ? OR target_player_id = ?", playerId, playerId).Delete(PlayerFriendship{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("player_id = ?", playerId).Where("`rank` <> ?", groupRankOwner).Delete(GroupMember{}).Error; err != nil {
+		return err
+	}
+
+	return tx.Where("player_id = ?", playerId).Delete(GroupJoinRequest{}).Error
+}
+
+func processAccountDeletions() (int, error) {
+	var requests []AccountDeletionRequest
+
+	var queryError = database.Model(AccountDeletionRequest{}).
+		Where("cancelled_at IS NULL").
+		Where("completed_at IS NULL").
+		Where("scheduled_for <= ?", time.Now().In(location)).
+		Find(&requests).
+		Error
+
+	if queryError != nil {
+		return 0, queryError
+	}
+
+	processed := 0
+
+	for _, request := range requests {
+		transactionError := database.Transaction(func(tx *gorm.DB) error {
+			if err := anonymizePlayer(tx, request.PlayerId); err != nil {
+				return err
+			}
+
+			return tx.Model(&request).Update("completed_at", time.Now().In(location)).Error
+		})
+
+		if transactionError != nil {
+			return processed, transactionError
+		}
+
+		if err := revokeSessions(request.PlayerId); err != nil {
+			log.Println("Failed to revoke sessions for deleted player:", err)
+		}
+
+		processed++
+	}
+
+	return processed, nil
+}
+
+func startAccountDeletionWorker() {
+	interval := time.Duration(getEnvAsInt("ACCOUNT_DELETION_INTERVAL_MINUTES", 60)) * time.Minute
+
+	go func() {
+		for {
+			if _, err := processAccountDeletions(); err != nil {
+				log.Println("Failed to process account deletions:", err)
+			}
+
+			time.Sleep(interval)
+		}
+	}()
+}
+
+func AccountDeletionStatusHandler(w http.ResponseWriter, r *http.Request) {
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	request, requestError := findPendingDeletion(player.ID)
+
+	if requestError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: requestError.Error()})
+		return
+	}
+
+	if request == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You haven't requested account deletion"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(newAccountDeletionResponse(*request))
+}
+
+func RequestAccountDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	var req PasswordConfirmationRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(player.Password), []byte(req.Password)) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Your current password is incorrect"})
+		return
+	}
+
+	existing, existingError := findPendingDeletion(player.ID)
+
+	if existingError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: existingError.Error()})
+		return
+	}
+
+	if existing != nil && existing.ConfirmedAt != nil {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Your account is already scheduled for deletion"})
+		return
+	}
+
+	now := time.Now().In(location)
+
+	if existing != nil {
+		database.Model(existing).Update("cancelled_at", now)
+	}
+
+	seedRandom()
+
+	request := AccountDeletionRequest{
+		PlayerId:  player.ID,
+		Token:     randSeq(30),
+		CreatedAt: now,
+	}
+
+	if err := database.Create(&request).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	sendAccountDeletionEmail(player, request.Token)
+	recordAudit(r, &player.ID, &player.ID, auditDeletionRequested, nil)
+
+	json.NewEncoder(w).Encode(newAccountDeletionResponse(request))
+}
+
+func ConfirmAccountDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	var request AccountDeletionRequest
+
+	var queryError = database.Model(AccountDeletionRequest{}).
+		Where("token = ?", mux.Vars(r)["token"]).
+		Where("cancelled_at IS NULL").
+		Where("completed_at IS NULL").
+		First(&request).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "This link is invalid or has been cancelled"})
+		return
+	}
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	if request.ConfirmedAt != nil {
+		json.NewEncoder(w).Encode(newAccountDeletionResponse(request))
+		return
+	}
+
+	now := time.Now().In(location)
+	scheduledFor := now.AddDate(0, 0, getEnvAsInt("ACCOUNT_DELETION_GRACE_DAYS", 14))
+	request.ConfirmedAt = &now
+	request.ScheduledFor = &scheduledFor
+
+	var updateError = database.Model(&request).
+		Updates(map[string]interface{}{
+			"confirmed_at":  now,
+			"scheduled_for": scheduledFor,
+		}).
+		Error
+
+	if updateError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: updateError.Error()})
+		return
+	}
+
+	recordAudit(r, nil, &request.PlayerId, auditDeletionConfirmed, map[string]interface{}{"scheduled_for": scheduledFor})
+
+	json.NewEncoder(w).Encode(newAccountDeletionResponse(request))
+}
+
+func CancelAccountDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	request, requestError := findPendingDeletion(player.ID)
+
+	if requestError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: requestError.Error()})
+		return
+	}
+
+	if request == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You haven't requested account deletion"})
+		return
+	}
+
+	now := time.Now().In(location)
+	request.CancelledAt = &now
+
+	if err := database.Model(request).Update("cancelled_at", now).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	recordAudit(r, &player.ID, &player.ID, auditDeletionCancelled, nil)
+
+	json.NewEncoder(w).Encode(newAccountDeletionResponse(*request))
+}
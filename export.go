@@ -0,0 +1,165 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"sort"
+	"time"
+)
+
+type exportedSsoToken struct {
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type exportedResetLink struct {
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+func buildPlayerExport(player Player) (map[string]interface{}, error) {
+	var data PlayerData
+	var avatarData PlayerAvatarData
+	var gameSettings PlayerGameSettings
+	var navigatorSettings PlayerNavigatorSettings
+	var websiteData PlayerWebsiteData
+	var ssoTokens []PlayerSsoToken
+	var resetLinks []PlayerPasswordResetLink
+	var nameChanges []PlayerNameChange
+	var currencyTransactions []CurrencyTransaction
+	var auditEvents []AuditEvent
+
+	privacySettings, privacyError := loadPrivacySettings(player.ID)
+
+	if privacyError != nil {
+		return nil, privacyError
+	}
+
+	single := map[string]interface{}{
+		"player_data":        &data,
+		"avatar_data":        &avatarData,
+		"game_settings":      &gameSettings,
+		"navigator_settings": &navigatorSettings,
+		"website_data":       &websiteData,
+	}
+
+	for _, target := range single {
+		if err := database.Where("player_id = ?", player.ID).Find(target).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	many := []interface{}{&ssoTokens, &resetLinks, &nameChanges, &currencyTransactions}
+
+	for _, target := range many {
+		if err := database.Where("player_id = ?", player.ID).Order("id ASC").Find(target).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if err := database.Where("target_player_id = ?", player.ID).Order("id ASC").Find(&auditEvents).Error; err != nil {
+		return nil, err
+	}
+
+	exportedTokens := make([]exportedSsoToken, 0, len(ssoTokens))
+
+	for _, token := range ssoTokens {
+		exportedTokens = append(exportedTokens, exportedSsoToken{CreatedAt: token.CreatedAt, ExpiresAt: token.ExpiresAt})
+	}
+
+	exportedLinks := make([]exportedResetLink, 0, len(resetLinks))
+
+	for _, link := range resetLinks {
+		exportedLinks = append(exportedLinks, exportedResetLink{CreatedAt: link.CreatedAt, ExpiresAt: link.ExpiresAt, UsedAt: link.UsedAt})
+	}
+
+	exportedEvents := make([]SecurityActivityResponse, 0, len(auditEvents))
+
+	for _, event := range auditEvents {
+		exportedEvents = append(exportedEvents, SecurityActivityResponse{
+			Action:    event.Action,
+			Ip:        event.Ip,
+			UserAgent: event.UserAgent,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+
+	return map[string]interface{}{
+		"player":                newPrivatePlayerResponse(player, includeSet{}),
+		"player_data":           data,
+		"avatar_data":           avatarData,
+		"game_settings":         gameSettings,
+		"navigator_settings":    navigatorSettings,
+		"privacy_settings":      newPrivacySettingsResponse(privacySettings),
+		"website_data":          websiteData,
+		"sso_tokens":            exportedTokens,
+		"password_resets":       exportedLinks,
+		"name_changes":          newNameChangeResponses(nameChanges),
+		"currency_transactions": currencyTransactions,
+		"audit_events":          exportedEvents,
+		"exported_at":           time.Now().In(location),
+	}, nil
+}
+
+func DataExportHandler(w http.ResponseWriter, r *http.Request) {
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	export, exportError := buildPlayerExport(player)
+
+	if exportError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: exportError.Error()})
+		return
+	}
+
+	recordAudit(r, &player.ID, &player.ID, auditDataExported, map[string]interface{}{"format": r.URL.Query().Get("format")})
+
+	filename := fmt.Sprintf("%s-export-%s", player.Username, time.Now().In(location).Format("20060102"))
+
+	if r.URL.Query().Get("format") != "zip" {
+		w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+".json\"")
+		json.NewEncoder(w).Encode(export)
+		return
+	}
+
+	sections := make([]string, 0, len(export))
+
+	for section := range export {
+		sections = append(sections, section)
+	}
+
+	sort.Strings(sections)
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+".zip\"")
+
+	archive := zip.NewWriter(w)
+
+	for _, section := range sections {
+		file, err := archive.Create(section + ".json")
+
+		if err != nil {
+			return
+		}
+
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(export[section]); err != nil {
+			return
+		}
+	}
+
+	archive.Close()
+}
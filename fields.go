@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+type fieldSet map[string]fieldSet
+
+type includeSet map[string]bool
+
+func parseFieldSet(raw string) fieldSet {
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+
+	set := fieldSet{}
+
+	for _, path := range strings.Split(raw, ",") {
+		path = strings.TrimSpace(path)
+
+		if path == "" {
+			continue
+		}
+
+		node := set
+		parts := strings.Split(path, ".")
+
+		for i, part := range parts {
+			child, exists := node[part]
+
+			if i == len(parts)-1 {
+				node[part] = nil
+				break
+			}
+
+			if exists && child == nil {
+				break
+			}
+
+			if !exists {
+				child = fieldSet{}
+				node[part] = child
+			}
+
+			node = child
+		}
+	}
+
+	return set
+}
+
+func (set fieldSet) filter(value interface{}) interface{} {
+	if set == nil {
+		return value
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		filtered := map[string]interface{}{}
+
+		for key, child := range set {
+			if fieldValue, ok := typed[key]; ok {
+				filtered[key] = child.filter(fieldValue)
+			}
+		}
+
+		return filtered
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = set.filter(item)
+		}
+
+		return typed
+	}
+
+	return value
+}
+
+func applyFieldSet(r *http.Request, value interface{}) (interface{}, error) {
+	fields := parseFieldSet(r.URL.Query().Get("fields"))
+
+	if fields == nil {
+		return value, nil
+	}
+
+	encoded, err := json.Marshal(value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+
+	if err := json.Unmarshal(encoded, &generic); err != nil {
+		return nil, err
+	}
+
+	return fields.filter(generic), nil
+}
+
+func encodeResponse(w http.ResponseWriter, r *http.Request, value interface{}) {
+	filtered, err := applyFieldSet(r, value)
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(filtered)
+}
+
+func parseIncludes(r *http.Request, allowed []string, defaults []string) includeSet {
+	names := defaults
+
+	if raw, requested := r.URL.Query()["include"]; requested {
+		names = strings.Split(strings.Join(raw, ","), ",")
+	}
+
+	includes := includeSet{}
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+
+		for _, candidate := range allowed {
+			if candidate == name {
+				includes[name] = true
+			}
+		}
+	}
+
+	return includes
+}
@@ -0,0 +1,542 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"time"
+)
+
+const (
+	friendshipPending  = 0
+	friendshipAccepted = 1
+	friendshipBlocked  = 2
+)
+
+func loadFriendIds(playerId int64) ([]int64, error) {
+	var friendships []PlayerFriendship
+
+	var queryError = database.Model(PlayerFriendship{}).
+		Where("status = ?", friendshipAccepted).
+		Where("origin_player_id = ? OR target_player_id = ?", playerId, playerId).
+		Find(&friendships).
+		Error
+
+	seen := map[int64]bool{}
+	var friendIds []int64
+
+	for _, friendship := range friendships {
+		friendId := friendship.TargetPlayerId
+
+		if friendId == playerId {
+			friendId = friendship.OriginPlayerId
+		}
+
+		if !seen[friendId] {
+			seen[friendId] = true
+			friendIds = append(friendIds, friendId)
+		}
+	}
+
+	return friendIds, queryError
+}
+
+func loadMutualFriendIds(playerId int64, otherPlayerId int64) ([]int64, error) {
+	friendIds, friendsError := loadFriendIds(playerId)
+
+	if friendsError != nil {
+		return nil, friendsError
+	}
+
+	otherFriendIds, otherError := loadFriendIds(otherPlayerId)
+
+	if otherError != nil {
+		return nil, otherError
+	}
+
+	isFriend := map[int64]bool{}
+
+	for _, friendId := range friendIds {
+		isFriend[friendId] = true
+	}
+
+	var mutualIds []int64
+
+	for _, friendId := range otherFriendIds {
+		if isFriend[friendId] {
+			mutualIds = append(mutualIds, friendId)
+		}
+	}
+
+	return mutualIds, nil
+}
+
+func findFriendship(playerId int64, otherPlayerId int64) (PlayerFriendship, error) {
+	var friendship PlayerFriendship
+
+	var queryError = database.Model(PlayerFriendship{}).
+		Where("(origin_player_id = ? AND target_player_id = ?) OR (origin_player_id = ? AND target_player_id = ?)",
+			playerId, otherPlayerId, otherPlayerId, playerId).
+		Order("status DESC").
+		First(&friendship).
+		Error
+
+	return friendship, queryError
+}
+
+func areFriends(playerId int64, otherPlayerId int64) (bool, error) {
+	friendship, queryError := findFriendship(playerId, otherPlayerId)
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+
+	return queryError == nil && friendship.Status == friendshipAccepted, queryError
+}
+
+func friendLimit(playerId int64) (int, error) {
+	var limits struct {
+		Limit int
+	}
+
+	var queryError = database.Table("roles").
+		Select("COALESCE(MAX(roles.friend_limit), 0) AS `limit`").
+		Joins("INNER JOIN player_role ON player_role.role_id = roles.id").
+		Where("player_role.player_id = ?", playerId).
+		Scan(&limits).
+		Error
+
+	if queryError != nil && !errors.Is(queryError, gorm.ErrRecordNotFound) {
+		return 0, queryError
+	}
+
+	if limits.Limit > 0 {
+		return limits.Limit, nil
+	}
+
+	return getEnvAsInt("FRIEND_LIMIT", 300), nil
+}
+
+func hasReachedFriendLimit(playerId int64) (bool, error) {
+	limit, limitError := friendLimit(playerId)
+
+	if limitError != nil {
+		return false, limitError
+	}
+
+	friendIds, friendsError := loadFriendIds(playerId)
+
+	if friendsError != nil {
+		return false, friendsError
+	}
+
+	return len(friendIds) >= limit, nil
+}
+
+func FriendsHandler(w http.ResponseWriter, r *http.Request) {
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	friendIds, friendsError := loadFriendIds(player.ID)
+
+	if friendsError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: friendsError.Error()})
+		return
+	}
+
+	cards, cardsError := loadPlayerCards(friendIds)
+
+	if cardsError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: cardsError.Error()})
+		return
+	}
+
+	encodeResponse(w, r, cards)
+}
+
+func FriendRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	var requests []PlayerFriendship
+
+	var queryError = database.Model(PlayerFriendship{}).
+		Where("target_player_id = ?", player.ID).
+		Where("status = ?", friendshipPending).
+		Order("id DESC").
+		Find(&requests).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	senderIds := make([]int64, 0, len(requests))
+
+	for _, request := range requests {
+		senderIds = append(senderIds, request.OriginPlayerId)
+	}
+
+	cards, cardsError := loadPlayerCards(senderIds)
+
+	if cardsError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: cardsError.Error()})
+		return
+	}
+
+	cardsById := map[int64]PlayerCardResponse{}
+
+	for _, card := range cards {
+		cardsById[card.ID] = card
+	}
+
+	responses := make([]FriendRequestResponse, 0, len(requests))
+
+	for _, request := range requests {
+		responses = append(responses, FriendRequestResponse{
+			ID:        request.ID,
+			Player:    cardsById[request.OriginPlayerId],
+			CreatedAt: request.CreatedAt,
+		})
+	}
+
+	encodeResponse(w, r, responses)
+}
+
+func SendFriendRequestHandler(w http.ResponseWriter, r *http.Request) {
+	var req FriendRequestRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	var target Player
+
+	var targetError = database.Model(Player{}).
+		Where("username = ?", req.Username).
+		First(&target).
+		Error
+
+	if targetError != nil || target.ID == player.ID {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested player couldn't be found"})
+		return
+	}
+
+	existing, existingError := findFriendship(player.ID, target.ID)
+
+	if existingError != nil && !errors.Is(existingError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: existingError.Error()})
+		return
+	}
+
+	if existingError == nil {
+		switch {
+		case existing.Status == friendshipBlocked:
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You can't send a friend request to this player"})
+			return
+		case existing.Status == friendshipAccepted:
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You're already friends"})
+			return
+		case existing.OriginPlayerId == player.ID:
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You've already sent a friend request to this player"})
+			return
+		}
+
+		acceptFriendship(w, existing)
+		return
+	}
+
+	if reached, err := hasReachedFriendLimit(player.ID); err != nil || reached {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Your friends list is full"})
+		return
+	}
+
+	friendship := PlayerFriendship{
+		OriginPlayerId: player.ID,
+		TargetPlayerId: target.ID,
+		Status:         friendshipPending,
+		CreatedAt:      time.Now().In(location),
+		UpdatedAt:      time.Now().In(location),
+	}
+
+	if err := database.Create(&friendship).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Your friend request has been sent"})
+}
+
+func AcceptFriendRequestHandler(w http.ResponseWriter, r *http.Request) {
+	_, request, found := findIncomingFriendRequest(w, r)
+
+	if !found {
+		return
+	}
+
+	acceptFriendship(w, request)
+}
+
+func DeclineFriendRequestHandler(w http.ResponseWriter, r *http.Request) {
+	_, request, found := findIncomingFriendRequest(w, r)
+
+	if !found {
+		return
+	}
+
+	if err := database.Delete(&request).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The friend request has been declined"})
+}
+
+func RemoveFriendHandler(w http.ResponseWriter, r *http.Request) {
+	friendId, idError := parseIdParam(r, "id")
+
+	if idError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid player id"})
+		return
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	var deleteError = database.
+		Where("(origin_player_id = ? AND target_player_id = ?) OR (origin_player_id = ? AND target_player_id = ?)",
+			player.ID, friendId, friendId, player.ID).
+		Where("status = ?", friendshipAccepted).
+		Delete(PlayerFriendship{}).
+		Error
+
+	if deleteError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: deleteError.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The friend has been removed"})
+}
+
+func BlockPlayerHandler(w http.ResponseWriter, r *http.Request) {
+	targetId, idError := parseIdParam(r, "id")
+
+	if idError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid player id"})
+		return
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	if targetId == player.ID {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You can't block yourself"})
+		return
+	}
+
+	var targetCount int
+
+	if err := database.Model(Player{}).Where("id = ?", targetId).Count(&targetCount).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	if targetCount == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested player couldn't be found"})
+		return
+	}
+
+	transactionError := database.Transaction(func(tx *gorm.DB) error {
+		var deleteError = tx.
+			Where("(origin_player_id = ? AND target_player_id = ?) OR (origin_player_id = ? AND target_player_id = ? AND status <> ?)",
+				player.ID, targetId, targetId, player.ID, friendshipBlocked).
+			Delete(PlayerFriendship{}).
+			Error
+
+		if deleteError != nil {
+			return deleteError
+		}
+
+		block := PlayerFriendship{
+			OriginPlayerId: player.ID,
+			TargetPlayerId: targetId,
+			Status:         friendshipBlocked,
+			CreatedAt:      time.Now().In(location),
+			UpdatedAt:      time.Now().In(location),
+		}
+
+		return tx.Create(&block).Error
+	})
+
+	if transactionError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: transactionError.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The player has been blocked"})
+}
+
+func UnblockPlayerHandler(w http.ResponseWriter, r *http.Request) {
+	targetId, idError := parseIdParam(r, "id")
+
+	if idError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid player id"})
+		return
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	var deleteError = database.
+		Where("origin_player_id = ?", player.ID).
+		Where("target_player_id = ?", targetId).
+		Where("status = ?", friendshipBlocked).
+		Delete(PlayerFriendship{}).
+		Error
+
+	if deleteError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: deleteError.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The player has been unblocked"})
+}
+
+func findIncomingFriendRequest(w http.ResponseWriter, r *http.Request) (Player, PlayerFriendship, bool) {
+	var request PlayerFriendship
+
+	requestId, idError := parseIdParam(r, "id")
+
+	if idError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid friend request id"})
+		return Player{}, request, false
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return player, request, false
+	}
+
+	var queryError = database.Model(PlayerFriendship{}).
+		Where("id = ?", requestId).
+		Where("target_player_id = ?", player.ID).
+		Where("status = ?", friendshipPending).
+		First(&request).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested friend request couldn't be found"})
+		return player, request, false
+	}
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return player, request, false
+	}
+
+	return player, request, true
+}
+
+func acceptFriendship(w http.ResponseWriter, request PlayerFriendship) {
+	for _, playerId := range []int64{request.OriginPlayerId, request.TargetPlayerId} {
+		reached, limitError := hasReachedFriendLimit(playerId)
+
+		if limitError != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: limitError.Error()})
+			return
+		}
+
+		if reached {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: "One of your friends lists is full"})
+			return
+		}
+	}
+
+	var updateError = database.Model(&request).
+		Updates(map[string]interface{}{
+			"status":     friendshipAccepted,
+			"updated_at": time.Now().In(location),
+		}).
+		Error
+
+	if updateError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: updateError.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You're now friends"})
+}
@@ -0,0 +1,796 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	groupRankOwner  = "owner"
+	groupRankAdmin  = "admin"
+	groupRankMember = "member"
+)
+
+const (
+	groupJoinOpen    = "open"
+	groupJoinRequest = "request"
+	groupJoinClosed  = "closed"
+)
+
+type groupMemberCountRow struct {
+	GroupId int64
+	Count   int64
+}
+
+func preloadGroupOwner(query *gorm.DB) *gorm.DB {
+	return query.
+		Preload("Owner").
+		Preload("Owner.Data").
+		Preload("Owner.AvatarData").
+		Preload("Owner.PrivacySettings")
+}
+
+func loadGroupResponses(groups []Group) ([]GroupResponse, error) {
+	groupIds := make([]int64, 0, len(groups))
+
+	for _, group := range groups {
+		groupIds = append(groupIds, group.ID)
+	}
+
+	counts := map[int64]int64{}
+
+	if len(groupIds) == 0 {
+		return newGroupResponses(groups, counts), nil
+	}
+
+	var rows []groupMemberCountRow
+
+	var queryError = database.Table("group_members").
+		Select("group_id, COUNT(*) AS count").
+		Where("group_id IN (?)", groupIds).
+		Group("group_id").
+		Scan(&rows).
+		Error
+
+	for _, row := range rows {
+		counts[row.GroupId] = row.Count
+	}
+
+	return newGroupResponses(groups, counts), queryError
+}
+
+func loadPlayerGroups(playerId int64) ([]Group, error) {
+	var groups []Group
+
+	var queryError = preloadGroupOwner(database.Model(Group{})).
+		Joins("INNER JOIN group_members ON group_members.group_id = `groups`.id").
+		Where("group_members.player_id = ?", playerId).
+		Order("`groups`.name ASC").
+		Find(&groups).
+		Error
+
+	return groups, queryError
+}
+
+func findGroupMember(groupId int64, playerId int64) (GroupMember, error) {
+	var member GroupMember
+
+	var queryError = database.Model(GroupMember{}).
+		Where("group_id = ?", groupId).
+		Where("player_id = ?", playerId).
+		First(&member).
+		Error
+
+	return member, queryError
+}
+
+func findGroupParam(w http.ResponseWriter, r *http.Request) (Group, bool) {
+	var group Group
+
+	groupId, idError := parseIdParam(r, "id")
+
+	if idError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid group id"})
+		return group, false
+	}
+
+	var queryError = preloadGroupOwner(database.Model(Group{})).
+		Where("id = ?", groupId).
+		First(&group).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested group couldn't be found"})
+		return group, false
+	}
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return group, false
+	}
+
+	return group, true
+}
+
+func findGroupManager(w http.ResponseWriter, r *http.Request) (Group, GroupMember, bool) {
+	var member GroupMember
+
+	group, found := findGroupParam(w, r)
+
+	if !found {
+		return group, member, false
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return group, member, false
+	}
+
+	member, memberError := findGroupMember(group.ID, player.ID)
+
+	if memberError != nil && !errors.Is(memberError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: memberError.Error()})
+		return group, member, false
+	}
+
+	if memberError != nil || (member.Rank != groupRankOwner && member.Rank != groupRankAdmin) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Only group owners and admins can do this"})
+		return group, member, false
+	}
+
+	return group, member, true
+}
+
+func GroupsHandler(w http.ResponseWriter, r *http.Request) {
+	cursor, cursorError := decodeCursor(r.URL.Query().Get("cursor"))
+
+	if cursorError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid cursor"})
+		return
+	}
+
+	limit := parseLimit(r, 20, 100)
+	query := preloadGroupOwner(database.Model(Group{}))
+
+	if search := strings.TrimSpace(r.URL.Query().Get("q")); search != "" {
+		query = query.Where("name LIKE ?", "%"+escapeLike(search)+"%")
+	}
+
+	if cursor != nil {
+		query = query.Where("id < ?", cursor.ID)
+	}
+
+	var groups []Group
+
+	var queryError = query.
+		Order("id DESC").
+		Limit(limit + 1).
+		Find(&groups).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	nextCursor := ""
+
+	if len(groups) > limit {
+		groups = groups[:limit]
+		nextCursor = encodeCursor(pageCursor{ID: groups[len(groups)-1].ID})
+	}
+
+	responses, responsesError := loadGroupResponses(groups)
+
+	if responsesError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: responsesError.Error()})
+		return
+	}
+
+	encodePage(w, r, responses, nextCursor)
+}
+
+func GroupHandler(w http.ResponseWriter, r *http.Request) {
+	group, found := findGroupParam(w, r)
+
+	if !found {
+		return
+	}
+
+	respondWithGroup(w, r, group)
+}
+
+func GroupMembersHandler(w http.ResponseWriter, r *http.Request) {
+	group, found := findGroupParam(w, r)
+
+	if !found {
+		return
+	}
+
+	cursor, cursorError := decodeCursor(r.URL.Query().Get("cursor"))
+
+	if cursorError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid cursor"})
+		return
+	}
+
+	limit := parseLimit(r, 50, 200)
+	query := database.Model(GroupMember{}).Where("group_id = ?", group.ID)
+
+	if cursor != nil {
+		query = query.Where("id > ?", cursor.ID)
+	}
+
+	var members []GroupMember
+
+	var queryError = query.
+		Order("id ASC").
+		Limit(limit + 1).
+		Find(&members).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	nextCursor := ""
+
+	if len(members) > limit {
+		members = members[:limit]
+		nextCursor = encodeCursor(pageCursor{ID: members[len(members)-1].ID})
+	}
+
+	playerIds := make([]int64, 0, len(members))
+
+	for _, member := range members {
+		playerIds = append(playerIds, member.PlayerId)
+	}
+
+	cards, cardsError := loadPlayerCards(playerIds)
+
+	if cardsError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: cardsError.Error()})
+		return
+	}
+
+	cardsById := map[int64]PlayerCardResponse{}
+
+	for _, card := range cards {
+		cardsById[card.ID] = card
+	}
+
+	responses := make([]GroupMemberResponse, 0, len(members))
+
+	for _, member := range members {
+		responses = append(responses, GroupMemberResponse{
+			Player:   cardsById[member.PlayerId],
+			Rank:     member.Rank,
+			JoinedAt: member.CreatedAt,
+		})
+	}
+
+	encodePage(w, r, responses, nextCursor)
+}
+
+func CreateGroupHandler(w http.ResponseWriter, r *http.Request) {
+	var req GroupRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	group := Group{
+		OwnerId:   player.ID,
+		JoinMode:  groupJoinOpen,
+		CreatedAt: time.Now().In(location),
+	}
+
+	saveGroup(w, r, group, req)
+}
+
+func UpdateGroupHandler(w http.ResponseWriter, r *http.Request) {
+	group, _, found := findGroupManager(w, r)
+
+	if !found {
+		return
+	}
+
+	var req GroupRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	saveGroup(w, r, group, req)
+}
+
+func saveGroup(w http.ResponseWriter, r *http.Request, group Group, req GroupRequest) {
+	var existing int
+
+	var existingError = database.Model(Group{}).
+		Where("name = ?", req.Name).
+		Where("id <> ?", group.ID).
+		Count(&existing).
+		Error
+
+	if existingError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: existingError.Error()})
+		return
+	}
+
+	if existing > 0 {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "A group with this name already exists"})
+		return
+	}
+
+	group.Name = req.Name
+	group.Description = filterWords(req.Description)
+	group.BadgeCode = req.BadgeCode
+	group.UpdatedAt = time.Now().In(location)
+
+	if req.JoinMode != "" {
+		group.JoinMode = req.JoinMode
+	}
+
+	saveError := database.Transaction(func(tx *gorm.DB) error {
+		isNew := group.ID == 0
+
+		if err := tx.Set("gorm:save_associations", false).Save(&group).Error; err != nil {
+			return err
+		}
+
+		if !isNew {
+			return nil
+		}
+
+		owner := GroupMember{
+			GroupId:   group.ID,
+			PlayerId:  group.OwnerId,
+			Rank:      groupRankOwner,
+			CreatedAt: group.CreatedAt,
+		}
+
+		return tx.Create(&owner).Error
+	})
+
+	if saveError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: saveError.Error()})
+		return
+	}
+
+	var queryError = preloadGroupOwner(database.Model(Group{})).
+		Where("id = ?", group.ID).
+		First(&group).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	respondWithGroup(w, r, group)
+}
+
+func JoinGroupHandler(w http.ResponseWriter, r *http.Request) {
+	group, found := findGroupParam(w, r)
+
+	if !found {
+		return
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	_, memberError := findGroupMember(group.ID, player.ID)
+
+	if memberError == nil {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You're already a member of this group"})
+		return
+	}
+
+	if !errors.Is(memberError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: memberError.Error()})
+		return
+	}
+
+	switch group.JoinMode {
+	case groupJoinClosed:
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "This group isn't accepting new members"})
+		return
+	case groupJoinRequest:
+		var request GroupJoinRequest
+
+		var requestError = database.
+			Where(map[string]interface{}{"group_id": group.ID, "player_id": player.ID}).
+			Attrs(GroupJoinRequest{CreatedAt: time.Now().In(location)}).
+			FirstOrCreate(&request).
+			Error
+
+		if requestError != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: requestError.Error()})
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Your request to join has been sent"})
+		return
+	}
+
+	member := GroupMember{
+		GroupId:   group.ID,
+		PlayerId:  player.ID,
+		Rank:      groupRankMember,
+		CreatedAt: time.Now().In(location),
+	}
+
+	if err := database.Create(&member).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	respondWithGroup(w, r, group)
+}
+
+func LeaveGroupHandler(w http.ResponseWriter, r *http.Request) {
+	group, found := findGroupParam(w, r)
+
+	if !found {
+		return
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	member, memberError := findGroupMember(group.ID, player.ID)
+
+	if errors.Is(memberError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You're not a member of this group"})
+		return
+	}
+
+	if memberError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: memberError.Error()})
+		return
+	}
+
+	if member.Rank == groupRankOwner {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The owner can't leave their own group"})
+		return
+	}
+
+	if err := database.Delete(&member).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You've left the group"})
+}
+
+func GroupJoinRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	group, _, found := findGroupManager(w, r)
+
+	if !found {
+		return
+	}
+
+	var requests []GroupJoinRequest
+
+	var queryError = database.Model(GroupJoinRequest{}).
+		Where("group_id = ?", group.ID).
+		Order("id ASC").
+		Find(&requests).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	playerIds := make([]int64, 0, len(requests))
+
+	for _, request := range requests {
+		playerIds = append(playerIds, request.PlayerId)
+	}
+
+	cards, cardsError := loadPlayerCards(playerIds)
+
+	if cardsError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: cardsError.Error()})
+		return
+	}
+
+	cardsById := map[int64]PlayerCardResponse{}
+
+	for _, card := range cards {
+		cardsById[card.ID] = card
+	}
+
+	responses := make([]GroupJoinRequestResponse, 0, len(requests))
+
+	for _, request := range requests {
+		responses = append(responses, GroupJoinRequestResponse{
+			Player:      cardsById[request.PlayerId],
+			RequestedAt: request.CreatedAt,
+		})
+	}
+
+	encodeResponse(w, r, responses)
+}
+
+func AcceptGroupJoinRequestHandler(w http.ResponseWriter, r *http.Request) {
+	group, _, found := findGroupManager(w, r)
+
+	if !found {
+		return
+	}
+
+	request, requestFound := findGroupJoinRequest(w, r, group)
+
+	if !requestFound {
+		return
+	}
+
+	acceptError := database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&request).Error; err != nil {
+			return err
+		}
+
+		member := GroupMember{
+			GroupId:   group.ID,
+			PlayerId:  request.PlayerId,
+			Rank:      groupRankMember,
+			CreatedAt: time.Now().In(location),
+		}
+
+		return tx.Create(&member).Error
+	})
+
+	if acceptError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: acceptError.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The join request has been accepted"})
+}
+
+func DeclineGroupJoinRequestHandler(w http.ResponseWriter, r *http.Request) {
+	group, _, found := findGroupManager(w, r)
+
+	if !found {
+		return
+	}
+
+	request, requestFound := findGroupJoinRequest(w, r, group)
+
+	if !requestFound {
+		return
+	}
+
+	if err := database.Delete(&request).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The join request has been declined"})
+}
+
+func findGroupJoinRequest(w http.ResponseWriter, r *http.Request, group Group) (GroupJoinRequest, bool) {
+	var request GroupJoinRequest
+
+	playerId, idError := parseIdParam(r, "player_id")
+
+	if idError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid player id"})
+		return request, false
+	}
+
+	var queryError = database.Model(GroupJoinRequest{}).
+		Where("group_id = ?", group.ID).
+		Where("player_id = ?", playerId).
+		First(&request).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested join request couldn't be found"})
+		return request, false
+	}
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return request, false
+	}
+
+	return request, true
+}
+
+func UpdateGroupMemberRankHandler(w http.ResponseWriter, r *http.Request) {
+	group, manager, found := findGroupManager(w, r)
+
+	if !found {
+		return
+	}
+
+	if manager.Rank != groupRankOwner {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Only the group owner can change ranks"})
+		return
+	}
+
+	var req GroupRankRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	member, memberFound := findGroupMemberParam(w, r, group)
+
+	if !memberFound {
+		return
+	}
+
+	if member.Rank == groupRankOwner {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The owner's rank can't be changed"})
+		return
+	}
+
+	if err := database.Model(&member).Update("rank", req.Rank).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The member's rank has been updated"})
+}
+
+func RemoveGroupMemberHandler(w http.ResponseWriter, r *http.Request) {
+	group, manager, found := findGroupManager(w, r)
+
+	if !found {
+		return
+	}
+
+	member, memberFound := findGroupMemberParam(w, r, group)
+
+	if !memberFound {
+		return
+	}
+
+	if member.Rank == groupRankOwner || (member.Rank == groupRankAdmin && manager.Rank != groupRankOwner) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You can't remove this member"})
+		return
+	}
+
+	if err := database.Delete(&member).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The member has been removed"})
+}
+
+func findGroupMemberParam(w http.ResponseWriter, r *http.Request, group Group) (GroupMember, bool) {
+	playerId, idError := parseIdParam(r, "player_id")
+
+	if idError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid player id"})
+		return GroupMember{}, false
+	}
+
+	member, memberError := findGroupMember(group.ID, playerId)
+
+	if errors.Is(memberError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested member couldn't be found"})
+		return member, false
+	}
+
+	if memberError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: memberError.Error()})
+		return member, false
+	}
+
+	return member, true
+}
+
+func respondWithGroup(w http.ResponseWriter, r *http.Request, group Group) {
+	responses, responsesError := loadGroupResponses([]Group{group})
+
+	if responsesError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: responsesError.Error()})
+		return
+	}
+
+	encodeResponse(w, r, responses[0])
+}
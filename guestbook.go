@@ -0,0 +1,314 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	guestbookOpen     = "open"
+	guestbookFriends  = "friends"
+	guestbookDisabled = "disabled"
+)
+
+func isGuestbookMode(mode string) bool {
+	return mode == guestbookOpen || mode == guestbookFriends || mode == guestbookDisabled
+}
+
+func guestbookMode(settings PlayerPrivacySettings) string {
+	if settings.GuestbookMode == "" {
+		return guestbookOpen
+	}
+
+	return settings.GuestbookMode
+}
+
+func canAccessGuestbook(profilePlayerId int64, viewer *Player) (bool, error) {
+	if viewer != nil && viewer.ID == profilePlayerId {
+		return true, nil
+	}
+
+	settings, settingsError := loadPrivacySettings(profilePlayerId)
+
+	if settingsError != nil {
+		return false, settingsError
+	}
+
+	switch guestbookMode(settings) {
+	case guestbookDisabled:
+		return false, nil
+	case guestbookFriends:
+		if viewer == nil {
+			return false, nil
+		}
+
+		return areFriends(viewer.ID, profilePlayerId)
+	}
+
+	return true, nil
+}
+
+func preloadGuestbookAuthor(query *gorm.DB) *gorm.DB {
+	return query.
+		Preload("Author").
+		Preload("Author.Data").
+		Preload("Author.AvatarData").
+		Preload("Author.PrivacySettings")
+}
+
+func loadGuestbookEntries(profilePlayerId int64, beforeId int64, limit int) ([]GuestbookEntry, error) {
+	var entries []GuestbookEntry
+
+	query := preloadGuestbookAuthor(database.Model(GuestbookEntry{})).
+		Where("profile_player_id = ?", profilePlayerId)
+
+	if beforeId > 0 {
+		query = query.Where("id < ?", beforeId)
+	}
+
+	var queryError = query.
+		Order("id DESC").
+		Limit(limit).
+		Find(&entries).
+		Error
+
+	return entries, queryError
+}
+
+func findGuestbookProfile(w http.ResponseWriter, r *http.Request) (Player, bool) {
+	var player Player
+
+	var queryError = database.Model(Player{}).
+		Where("username = ?", mux.Vars(r)["username"]).
+		First(&player).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested profile couldn't be found"})
+		return player, false
+	}
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return player, false
+	}
+
+	return player, true
+}
+
+func GuestbookHandler(w http.ResponseWriter, r *http.Request) {
+	profile, found := findGuestbookProfile(w, r)
+
+	if !found {
+		return
+	}
+
+	allowed, accessError := canAccessGuestbook(profile.ID, getOptionalPlayer(r))
+
+	if accessError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: accessError.Error()})
+		return
+	}
+
+	if !allowed {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "This guestbook isn't available to you"})
+		return
+	}
+
+	cursor, cursorError := decodeCursor(r.URL.Query().Get("cursor"))
+
+	if cursorError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid cursor"})
+		return
+	}
+
+	limit := parseLimit(r, 20, 100)
+	beforeId := int64(0)
+
+	if cursor != nil {
+		beforeId = cursor.ID
+	}
+
+	entries, entriesError := loadGuestbookEntries(profile.ID, beforeId, limit+1)
+
+	if entriesError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: entriesError.Error()})
+		return
+	}
+
+	nextCursor := ""
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+		nextCursor = encodeCursor(pageCursor{ID: entries[len(entries)-1].ID})
+	}
+
+	encodePage(w, r, newGuestbookEntryResponses(entries), nextCursor)
+}
+
+func CreateGuestbookEntryHandler(w http.ResponseWriter, r *http.Request) {
+	profile, found := findGuestbookProfile(w, r)
+
+	if !found {
+		return
+	}
+
+	var req GuestbookEntryRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	req.Body = strings.TrimSpace(req.Body)
+
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	allowed, accessError := canAccessGuestbook(profile.ID, &player)
+
+	if accessError == nil && allowed && player.ID != profile.ID {
+		friendship, friendshipError := findFriendship(player.ID, profile.ID)
+
+		if friendshipError != nil && !errors.Is(friendshipError, gorm.ErrRecordNotFound) {
+			accessError = friendshipError
+		} else if friendshipError == nil && friendship.Status == friendshipBlocked {
+			allowed = false
+		}
+	}
+
+	if accessError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: accessError.Error()})
+		return
+	}
+
+	if !allowed {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You can't post in this guestbook"})
+		return
+	}
+
+	var recent int
+	window := time.Duration(getEnvAsInt("GUESTBOOK_RATE_WINDOW_SECONDS", 60)) * time.Second
+
+	var recentError = database.Model(GuestbookEntry{}).
+		Unscoped().
+		Where("author_id = ?", player.ID).
+		Where("created_at > ?", time.Now().In(location).Add(-window)).
+		Count(&recent).
+		Error
+
+	if recentError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: recentError.Error()})
+		return
+	}
+
+	if recent >= getEnvAsInt("GUESTBOOK_RATE_LIMIT", 3) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You're doing too much, slow down!"})
+		return
+	}
+
+	entry := GuestbookEntry{
+		ProfilePlayerId: profile.ID,
+		AuthorId:        player.ID,
+		Body:            filterWords(req.Body),
+		CreatedAt:       time.Now().In(location),
+	}
+
+	if err := database.Set("gorm:save_associations", false).Create(&entry).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	var queryError = preloadGuestbookAuthor(database.Model(GuestbookEntry{})).
+		Where("id = ?", entry.ID).
+		First(&entry).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	encodeResponse(w, r, newGuestbookEntryResponses([]GuestbookEntry{entry})[0])
+}
+
+func DeleteGuestbookEntryHandler(w http.ResponseWriter, r *http.Request) {
+	entryId, idError := parseIdParam(r, "id")
+
+	if idError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid guestbook entry id"})
+		return
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	var entry GuestbookEntry
+
+	var queryError = database.Model(GuestbookEntry{}).
+		Where("id = ?", entryId).
+		First(&entry).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested guestbook entry couldn't be found"})
+		return
+	}
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	if entry.AuthorId != player.ID && entry.ProfilePlayerId != player.ID {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You can only delete entries you wrote or that are on your profile"})
+		return
+	}
+
+	if err := database.Delete(&entry).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The guestbook entry has been deleted"})
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"github.com/go-oauth2/oauth2/v4"
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
@@ -11,14 +10,14 @@ import (
 	"golang.org/x/crypto/bcrypt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"time"
 )
 
 func TokenRequestHandler(w http.ResponseWriter, r *http.Request) {
-	err := oauthServer.HandleTokenRequest(w, r)
-	fmt.Println(err)
+	oauthServer.HandleTokenRequest(w, r)
 }
 
 func PlayerLoginHandler(w http.ResponseWriter, r *http.Request) {
@@ -47,10 +46,9 @@ func PlayerLoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fmt.Println(credentials.Password)
-	fmt.Println(player.Password)
-
 	if bcrypt.CompareHashAndPassword([]byte(player.Password), []byte(credentials.Password)) != nil {
+		recordAudit(r, nil, &player.ID, auditLoginFailed, nil)
+
 		w.WriteHeader(http.StatusUnauthorized)
 
 		response := map[string]string{
@@ -61,12 +59,26 @@ func PlayerLoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if rejectBannedPlayer(w, r, player) {
+		return
+	}
+
+	if err := recordLogin(player, r); err != nil {
+		log.Println("Failed to record login:", err)
+	}
+
+	if err := cancelDeletionOnLogin(r, player); err != nil {
+		log.Println("Failed to cancel pending account deletion:", err)
+	}
+
+	recordAudit(r, &player.ID, &player.ID, auditLogin, nil)
+
 	tokenInfo, tokenError := oauthServer.Manager.GenerateAccessToken(r.Context(), oauth2.PasswordCredentials, &oauth2.TokenGenerateRequest{
 		ClientID:     strconv.FormatInt(serviceClient.ID, 10),
 		ClientSecret: serviceClient.Secret,
 		Request:      r,
 		Scope:        "read",
-		UserID:       credentials.Username,
+		UserID:       strconv.FormatInt(player.ID, 10),
 	})
 
 	if tokenError != nil {
@@ -89,12 +101,12 @@ func PingHandler(w http.ResponseWriter, r *http.Request) {
 func PlayerRequestHandler(w http.ResponseWriter, r *http.Request) {
 	tokenInfo := r.Context().Value("tokenInfo").(oauth2.TokenInfo)
 
+	includes := parseIncludes(r, playerIncludes, defaultPlayerIncludes)
+
 	var player Player
 
-	var queryError = database.Model(Player{}).
-		Preload("Data").
-		Preload("AvatarData").
-		Where("username = ?", tokenInfo.GetUserID()).
+	var queryError = preloadPlayerIncludes(database.Model(Player{}), includes).
+		Where("id = ?", tokenInfo.GetUserID()).
 		First(&player).
 		Error
 
@@ -102,7 +114,7 @@ func PlayerRequestHandler(w http.ResponseWriter, r *http.Request) {
 		log.Fatalln(queryError)
 	}
 
-	json.NewEncoder(w).Encode(player)
+	encodeResponse(w, r, newPrivatePlayerResponse(player, includes))
 }
 
 func PlayerCreateHandler(w http.ResponseWriter, r *http.Request) {
@@ -121,14 +133,18 @@ func PlayerCreateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var foundPlayer Player
-	if err := database.Model(Player{}).Where("username = ?", req.Username).First(&foundPlayer).Error; err == nil {
+	switch availableError := checkUsernameAvailable(0, req.Username); {
+	case errors.Is(availableError, errUsernameReserved):
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "This username is reserved"})
+		return
+	case errors.Is(availableError, errUsernameTaken), errors.Is(availableError, errUsernameHeld):
 		w.WriteHeader(http.StatusForbidden)
 		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The username you've chosen has been taken"})
 		return
-	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+	case availableError != nil:
 		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: availableError.Error()})
 		return
 	}
 
@@ -143,6 +159,19 @@ func PlayerCreateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ban, banError := findRegistrationBan(getUserIp(r), getMachineId(r), req.Email)
+
+	if banError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: banError.Error()})
+		return
+	}
+
+	if ban != nil {
+		writeBanned(w, *ban)
+		return
+	}
+
 	if getEnvAsInt("MAX_ACCOUNTS_PER_IP", 5) != 0 {
 		var count int
 		if err := database.Model(PlayerWebsiteData{}).
@@ -165,6 +194,10 @@ func PlayerCreateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if rejectAbusiveRegistration(w, r, req) {
+		return
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		log.Fatalln(err)
@@ -197,6 +230,23 @@ func PlayerCreateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	openingBalances := map[string]int64{
+		"credits":  playerData.CreditBalance,
+		"pixels":   playerData.PixelBalance,
+		"seasonal": playerData.SeasonalBalance,
+	}
+
+	for currency, balance := range openingBalances {
+		if balance == 0 {
+			continue
+		}
+
+		if _, err := recordCurrencyTransaction(database, player.ID, currency, balance, balance, "registration", nil); err != nil {
+			log.Fatalln(err)
+			return
+		}
+	}
+
 	avatarData := PlayerAvatarData{
 		PlayerId:     player.ID,
 		FigureCode:   os.Getenv("DEFAULT_PLAYER_OUTFIT"),
@@ -238,7 +288,10 @@ func PlayerCreateHandler(w http.ResponseWriter, r *http.Request) {
 		sendWelcomeEmail(player)
 	}
 
-	json.NewEncoder(w).Encode(player)
+	player.Data = playerData
+	player.AvatarData = avatarData
+
+	encodeResponse(w, r, newPrivatePlayerResponse(player, includeSet{"data": true, "avatar_data": true}))
 }
 
 func PlayerSsoTokenHandler(w http.ResponseWriter, r *http.Request) {
@@ -247,7 +300,7 @@ func PlayerSsoTokenHandler(w http.ResponseWriter, r *http.Request) {
 	var player Player
 
 	var queryError = database.Model(Player{}).
-		Where("username = ?", tokenInfo.GetUserID()).
+		Where("id = ?", tokenInfo.GetUserID()).
 		First(&player).
 		Error
 
@@ -256,6 +309,10 @@ func PlayerSsoTokenHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if rejectBannedPlayer(w, r, player) {
+		return
+	}
+
 	seedRandom()
 
 	token := PlayerSsoToken{
@@ -272,49 +329,117 @@ func PlayerSsoTokenHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	json.NewEncoder(w).Encode(token)
+	recordAudit(r, &player.ID, &player.ID, auditSsoIssued, nil)
+
+	json.NewEncoder(w).Encode(SsoTokenResponse{Token: token.Token, ExpiresAt: token.ExpiresAt})
 }
 
 func SendForgotPasswordEmailHandler(w http.ResponseWriter, r *http.Request) {
-	var player Player
+	respondAfter := time.Now().Add(time.Duration(getEnvAsInt("PASSWORD_RESET_RESPONSE_MS", 500)) * time.Millisecond)
+	defer func() { time.Sleep(time.Until(respondAfter)) }()
 
-	var bodyMap map[string]interface{}
+	var req ForgotPasswordRequest
 
-	decoder := json.NewDecoder(r.Body)
-	_ = decoder.Decode(&bodyMap)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
 
-	email := bodyMap["email"].(string)
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	windowStart := time.Now().In(location).Add(-time.Hour)
+	maxResets := getEnvAsInt("MAX_PASSWORD_RESETS_PER_HOUR", 5)
+
+	var ipCount int
+
+	ipCountError := database.Model(AuditEvent{}).
+		Where("action = ?", auditPasswordResetRequested).
+		Where("ip = ?", getUserIp(r)).
+		Where("created_at > ?", windowStart).
+		Count(&ipCount).
+		Error
+
+	if ipCountError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: ipCountError.Error()})
+		return
+	}
+
+	if ipCount >= getEnvAsInt("MAX_PASSWORD_RESETS_PER_IP_PER_HOUR", 10) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You're doing too much, slow down!"})
+		return
+	}
+
+	var player Player
 
 	var queryError = database.Model(Player{}).
-		Where("email = ?", email).
+		Where("email = ?", req.Email).
 		First(&player).
 		Error
 
+	if queryError != nil && !errors.Is(queryError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
 	if queryError != nil {
-		log.Fatalln(queryError)
+		recordAudit(r, nil, nil, auditPasswordResetRequested, nil)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "We've sent you an email"})
 		return
 	}
 
+	recordAudit(r, nil, &player.ID, auditPasswordResetRequested, nil)
+
 	var count int
 
 	countError := database.Model(PlayerPasswordResetLink{}).
 		Where("player_id = ?", player.ID).
-		Where("expires_at > ?", time.Now().In(location)).
-		Where("used_at IS NULL").
+		Where("created_at > ?", windowStart).
 		Count(&count).
 		Error
 
 	if countError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(DefaultApiResponse{Message: countError.Error()})
 		return
 	}
 
-	if count > getEnvAsInt("MAX_PASSWORD_RESETS_PER_HOUR", 5) {
-		w.WriteHeader(429)
-		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You're doing too much, slow down!"})
+	if count >= maxResets {
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "We've sent you an email"})
+		return
+	}
+
+	resetLink, resetLinkError := createPasswordResetLink(player)
+
+	if resetLinkError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: resetLinkError.Error()})
 		return
 	}
 
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Println("Failed to send password reset email:", err)
+			}
+		}()
+
+		sendResetPasswordEmail(player, resetLink.Token)
+	}()
+
+	json.NewEncoder(w).Encode(DefaultApiResponse{Message: "We've sent you an email"})
+}
+
+func createPasswordResetLink(player Player) (PlayerPasswordResetLink, error) {
+	seedRandom()
+
 	resetLink := PlayerPasswordResetLink{
 		PlayerId:  player.ID,
 		Token:     randSeq(30),
@@ -322,15 +447,7 @@ func SendForgotPasswordEmailHandler(w http.ResponseWriter, r *http.Request) {
 		ExpiresAt: time.Now().In(location).Add(time.Minute * 10),
 	}
 
-	var resetLinkError = database.Create(&resetLink).Error
-
-	if resetLinkError != nil {
-		log.Fatalln(resetLinkError)
-		return
-	}
-
-	sendResetPasswordEmail(player, resetLink.Token)
-	json.NewEncoder(w).Encode(DefaultApiResponse{Message: "We've sent you an email"})
+	return resetLink, database.Create(&resetLink).Error
 }
 
 func GetResetPasswordLink(w http.ResponseWriter, r *http.Request) {
@@ -350,7 +467,7 @@ func GetResetPasswordLink(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	json.NewEncoder(w).Encode(resetLink)
+	json.NewEncoder(w).Encode(PasswordResetLinkResponse{ExpiresAt: resetLink.ExpiresAt})
 }
 
 func UseResetPasswordLink(w http.ResponseWriter, r *http.Request) {
@@ -391,7 +508,6 @@ func UseResetPasswordLink(w http.ResponseWriter, r *http.Request) {
 	}
 
 	hashedPassword, hashError := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	fmt.Println(password)
 
 	if hashError != nil {
 		log.Fatalln(hashError)
@@ -418,6 +534,8 @@ func UseResetPasswordLink(w http.ResponseWriter, r *http.Request) {
 		Model(&player).
 		Update("password", hashedPassword)
 
+	recordAudit(r, nil, &player.ID, auditPasswordResetCompleted, nil)
+
 	json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Your password has been updated"})
 }
 
@@ -427,7 +545,9 @@ func RolesHandler(w http.ResponseWriter, r *http.Request) {
 	var queryError = database.Model(&Role{}).
 		Preload("Players.Data").
 		Preload("Players.AvatarData").
-		Where("id > 1").
+		Preload("Players.PrivacySettings").
+		Where("is_hidden = ?", false).
+		Order("sort_order ASC").
 		Find(&roles).
 		Error
 
@@ -436,7 +556,13 @@ func RolesHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	json.NewEncoder(w).Encode(roles)
+	responses := make([]RoleResponse, 0, len(roles))
+
+	for _, role := range roles {
+		responses = append(responses, newRoleResponse(role))
+	}
+
+	encodeResponse(w, r, responses)
 }
 
 func UpdateSettingsHandler(w http.ResponseWriter, r *http.Request) {
@@ -456,7 +582,7 @@ func UpdateSettingsHandler(w http.ResponseWriter, r *http.Request) {
 	var queryError = database.Model(Player{}).
 		Preload("Data").
 		Preload("AvatarData").
-		Where("username = ?", tokenInfo.GetUserID()).
+		Where("id = ?", tokenInfo.GetUserID()).
 		First(&player).
 		Error
 
@@ -502,6 +628,11 @@ func UpdateSettingsHandler(w http.ResponseWriter, r *http.Request) {
 			Model(&player).
 			Update("password", hashedPassword)
 
+		recordAudit(r, &player.ID, &player.ID, auditPasswordChanged, nil)
+	}
+
+	if email != player.Email {
+		recordAudit(r, &player.ID, &player.ID, auditEmailChanged, map[string]interface{}{"from": player.Email, "to": email})
 	}
 
 	database.
@@ -517,20 +648,99 @@ func UpdateSettingsHandler(w http.ResponseWriter, r *http.Request) {
 
 func GetPlayerProfileHandler(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
+	includes := parseIncludes(r, playerIncludes, defaultProfileIncludes)
+
 	var player Player
 
-	var queryError = database.Model(Player{}).
-		Preload("Data").
-		Preload("AvatarData").
+	var queryError = preloadPlayerIncludes(database.Model(Player{}), includes).
 		Where("username = ?", params["username"]).
 		First(&player).
 		Error
 
 	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		if renamed, renamedError := findRenamedPlayer(params["username"]); renamedError == nil {
+			w.Header().Set("Location", "/profile/"+url.PathEscape(renamed.Username))
+			w.WriteHeader(http.StatusFound)
+			json.NewEncoder(w).Encode(ProfileRedirectResponse{Message: "This player has changed their username", Username: renamed.Username})
+			return
+		}
+
 		w.WriteHeader(404)
 		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested profile couldn't be found"})
 		return
 	}
 
-	json.NewEncoder(w).Encode(player)
+	settings, settingsError := loadPrivacySettings(player.ID)
+
+	if settingsError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: settingsError.Error()})
+		return
+	}
+
+	response := newPlayerResponse(player, includes)
+
+	if response.Data != nil && settings.HideOnlineStatus {
+		response.Data = &PublicPlayerDataResponse{}
+	}
+
+	viewer := getOptionalPlayer(r)
+
+	if includes["mutual_friends"] && viewer != nil && viewer.ID != player.ID {
+		mutualIds, mutualError := loadMutualFriendIds(viewer.ID, player.ID)
+
+		if mutualError == nil {
+			response.MutualFriends, mutualError = loadPlayerCards(mutualIds)
+		}
+
+		if mutualError != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: mutualError.Error()})
+			return
+		}
+	}
+
+	if includes["rooms"] && (!settings.HideRooms || (viewer != nil && viewer.ID == player.ID)) {
+		rooms, roomsError := loadPlayerRooms(player.ID, 0, getEnvAsInt("PROFILE_ROOMS", 10))
+
+		if roomsError != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: roomsError.Error()})
+			return
+		}
+
+		response.Rooms = newRoomResponses(rooms)
+	}
+
+	if includes["groups"] {
+		groups, groupsError := loadPlayerGroups(player.ID)
+
+		if groupsError == nil {
+			response.Groups, groupsError = loadGroupResponses(groups)
+		}
+
+		if groupsError != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: groupsError.Error()})
+			return
+		}
+	}
+
+	if includes["guestbook"] {
+		allowed, accessError := canAccessGuestbook(player.ID, viewer)
+
+		if accessError == nil && allowed {
+			var entries []GuestbookEntry
+			entries, accessError = loadGuestbookEntries(player.ID, 0, getEnvAsInt("GUESTBOOK_PROFILE_ENTRIES", 5))
+			response.Guestbook = newGuestbookEntryResponses(entries)
+		}
+
+		if accessError != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: accessError.Error()})
+			return
+		}
+	}
+
+	encodeResponse(w, r, response)
 }
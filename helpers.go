@@ -1,13 +1,18 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"github.com/go-oauth2/oauth2/v4"
+	"github.com/go-sql-driver/mysql"
+	"github.com/gorilla/mux"
 	"gopkg.in/gomail.v2"
 	"math/rand"
 	"net/http"
 	"net/mail"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -52,16 +57,11 @@ func isValidEmail(email string) bool {
 }
 
 func getUserIp(r *http.Request) string {
-	IPAddress := r.Header.Get("X-Real-Ip")
-
-	if IPAddress == "" {
-		IPAddress = r.Header.Get("X-Forwarded-For")
+	if IPAddress, ok := r.Context().Value("clientIp").(string); ok {
+		return IPAddress
 	}
 
-	if IPAddress == "" {
-		IPAddress = r.RemoteAddr
-	}
-	return IPAddress
+	return resolveClientIp(r)
 }
 
 func seedRandom() {
@@ -115,3 +115,73 @@ func sendResetPasswordEmail(player Player, resetId string) {
 		panic(err)
 	}
 }
+
+func getAuthenticatedPlayer(r *http.Request) (Player, error) {
+	tokenInfo := r.Context().Value("tokenInfo").(oauth2.TokenInfo)
+
+	return findTokenPlayer(tokenInfo)
+}
+
+func getOptionalPlayer(r *http.Request) *Player {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		return nil
+	}
+
+	tokenInfo, tokenError := oauthServer.ValidationBearerToken(r)
+
+	if tokenError != nil || tokenInfo == nil {
+		return nil
+	}
+
+	player, playerError := findTokenPlayer(tokenInfo)
+
+	if playerError != nil {
+		return nil
+	}
+
+	if revoked, revokedError := isTokenRevoked(player.ID, tokenInfo); revokedError != nil || revoked {
+		return nil
+	}
+
+	return &player
+}
+
+func findTokenPlayer(tokenInfo oauth2.TokenInfo) (Player, error) {
+	var player Player
+
+	var queryError = database.Model(Player{}).
+		Where("id = ?", tokenInfo.GetUserID()).
+		First(&player).
+		Error
+
+	return player, queryError
+}
+
+func isDuplicateKeyError(err error) bool {
+	var mysqlError *mysql.MySQLError
+	return errors.As(err, &mysqlError) && mysqlError.Number == 1062
+}
+
+func parseIdParam(r *http.Request, name string) (int64, error) {
+	return strconv.ParseInt(mux.Vars(r)[name], 10, 64)
+}
+
+func sendAccountDeletionEmail(player Player, token string) {
+	siteUrl := os.Getenv("SITE_URL")
+	siteName := os.Getenv("SITE_NAME")
+	subject := fmt.Sprintf("Confirm %s account deletion for %s", siteName, player.Username)
+	confirmLink := fmt.Sprintf("%s/account-deletion/%s", siteUrl, token)
+	body := fmt.Sprintf("We received a request to delete your account. If this was you, confirm it using the following link.<br><a href=\"%s\">%s</a><br><br>Your account will be deleted %d days after you confirm, and logging in before then lets you cancel.", confirmLink, confirmLink, getEnvAsInt("ACCOUNT_DELETION_GRACE_DAYS", 14))
+
+	m := gomail.NewMessage()
+
+	m.SetHeader("From", os.Getenv("MAIL_FROM_ADDRESS"))
+	m.SetHeader("To", player.Email)
+	m.SetHeader("Subject", subject)
+	m.SetBody("text/html", body)
+
+	if err := eDialer.DialAndSend(m); err != nil {
+		fmt.Println(err)
+		panic(err)
+	}
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-oauth2/oauth2/v4"
+	"github.com/go-playground/validator/v10"
+	"github.com/jinzhu/gorm"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type housekeepingPlayerRow struct {
+	ID        int64
+	Username  string
+	Email     string
+	CreatedAt time.Time
+	InitialIp string
+	LastIp    string
+	LastLogin *time.Time
+}
+
+func (row housekeepingPlayerRow) response() HousekeepingPlayerResponse {
+	return HousekeepingPlayerResponse{
+		ID:        row.ID,
+		Username:  row.Username,
+		Email:     row.Email,
+		CreatedAt: row.CreatedAt,
+		InitialIp: row.InitialIp,
+		LastIp:    row.LastIp,
+		LastLogin: row.LastLogin,
+	}
+}
+
+func housekeepingPlayerQuery() *gorm.DB {
+	return database.Table("players").
+		Select("players.id, players.username, players.email, players.created_at, " +
+			"player_website_data.initial_ip, player_website_data.last_ip, player_website_data.last_login").
+		Joins("LEFT JOIN player_website_data ON player_website_data.player_id = players.id")
+}
+
+func revokeSessions(playerId int64) error {
+	return database.
+		Where(PlayerSessionRevocation{PlayerId: playerId}).
+		Assign(PlayerSessionRevocation{RevokedAt: time.Now().In(location)}).
+		FirstOrCreate(&PlayerSessionRevocation{}).
+		Error
+}
+
+func isTokenRevoked(playerId int64, tokenInfo oauth2.TokenInfo) (bool, error) {
+	var revocation PlayerSessionRevocation
+
+	var queryError = database.Model(PlayerSessionRevocation{}).
+		Where("player_id = ?", playerId).
+		First(&revocation).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+
+	return queryError == nil && tokenInfo.GetAccessCreateAt().Before(revocation.RevokedAt), queryError
+}
+
+func findHousekeepingPlayer(w http.ResponseWriter, r *http.Request) (Player, Player, bool) {
+	var player Player
+
+	actor, actorError := getAuthenticatedPlayer(r)
+
+	if actorError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: actorError.Error()})
+		return actor, player, false
+	}
+
+	playerId, idError := parseIdParam(r, "id")
+
+	if idError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid player id"})
+		return actor, player, false
+	}
+
+	var queryError = preloadPlayerIncludes(database.Model(Player{}), includeSet{"data": true, "avatar_data": true, "roles": true}).
+		Where("id = ?", playerId).
+		First(&player).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested player couldn't be found"})
+		return actor, player, false
+	}
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return actor, player, false
+	}
+
+	return actor, player, true
+}
+
+func HousekeepingSearchHandler(w http.ResponseWriter, r *http.Request) {
+	actor, actorError := getAuthenticatedPlayer(r)
+
+	if actorError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: actorError.Error()})
+		return
+	}
+
+	cursor, cursorError := decodeCursor(r.URL.Query().Get("cursor"))
+
+	if cursorError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid cursor"})
+		return
+	}
+
+	limit := parseLimit(r, 25, 100)
+	search := strings.TrimSpace(r.URL.Query().Get("q"))
+	field := r.URL.Query().Get("by")
+	query := housekeepingPlayerQuery()
+
+	switch field {
+	case "email":
+		query = query.Where("players.email LIKE ?", escapeLike(search)+"%")
+	case "ip":
+		query = query.Where("player_website_data.initial_ip = ? OR player_website_data.last_ip = ?", search, search)
+	default:
+		field = "username"
+		query = query.Where("players.username LIKE ?", escapeLike(search)+"%")
+	}
+
+	if cursor != nil {
+		query = query.Where("players.id < ?", cursor.ID)
+	}
+
+	var rows []housekeepingPlayerRow
+
+	var queryError = query.
+		Order("players.id DESC").
+		Limit(limit + 1).
+		Scan(&rows).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	nextCursor := ""
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		nextCursor = encodeCursor(pageCursor{ID: rows[len(rows)-1].ID})
+	}
+
+	responses := make([]HousekeepingPlayerResponse, 0, len(rows))
+
+	for _, row := range rows {
+		responses = append(responses, row.response())
+	}
+
+	recordAudit(r, &actor.ID, nil, auditHousekeepingSearch, map[string]interface{}{"by": field, "q": search})
+
+	encodePage(w, r, responses, nextCursor)
+}
+
+func HousekeepingPlayerHandler(w http.ResponseWriter, r *http.Request) {
+	actor, player, found := findHousekeepingPlayer(w, r)
+
+	if !found {
+		return
+	}
+
+	respondWithHousekeepingPlayer(w, r, player)
+	recordAudit(r, &actor.ID, &player.ID, auditHousekeepingView, nil)
+}
+
+func HousekeepingUpdatePlayerHandler(w http.ResponseWriter, r *http.Request) {
+	actor, player, found := findHousekeepingPlayer(w, r)
+
+	if !found {
+		return
+	}
+
+	var req HousekeepingPlayerUpdateRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	changes := map[string]interface{}{}
+
+	for column, value := range map[string]*string{"username": req.Username, "email": req.Email} {
+		if value == nil {
+			continue
+		}
+
+		var taken int
+
+		var takenError = database.Model(Player{}).
+			Where(column+" = ?", *value).
+			Where("id <> ?", player.ID).
+			Count(&taken).
+			Error
+
+		if takenError != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: takenError.Error()})
+			return
+		}
+
+		if taken > 0 {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The " + column + " is already in use"})
+			return
+		}
+
+		changes[column] = *value
+	}
+
+	avatarChanges := map[string]interface{}{}
+
+	if req.Motto != nil {
+		avatarChanges["motto"] = *req.Motto
+	}
+
+	if req.Figure != nil {
+		avatarChanges["figure_code"] = *req.Figure
+	}
+
+	updateError := database.Transaction(func(tx *gorm.DB) error {
+		if username, renamed := changes["username"].(string); renamed && username != player.Username {
+			if _, err := changeUsername(tx, player, username, 0, &actor.ID); err != nil {
+				return err
+			}
+		}
+
+		if len(changes) > 0 {
+			if err := tx.Model(Player{}).Where("id = ?", player.ID).Updates(changes).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(avatarChanges) > 0 {
+			return tx.Model(PlayerAvatarData{}).Where("player_id = ?", player.ID).Updates(avatarChanges).Error
+		}
+
+		return nil
+	})
+
+	if updateError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: updateError.Error()})
+		return
+	}
+
+	for column, value := range avatarChanges {
+		changes[column] = value
+	}
+
+	recordAudit(r, &actor.ID, &player.ID, auditHousekeepingUpdate, changes)
+
+	_, player, found = findHousekeepingPlayer(w, r)
+
+	if found {
+		respondWithHousekeepingPlayer(w, r, player)
+	}
+}
+
+func HousekeepingPasswordResetHandler(w http.ResponseWriter, r *http.Request) {
+	actor, player, found := findHousekeepingPlayer(w, r)
+
+	if !found {
+		return
+	}
+
+	resetLink, resetError := createPasswordResetLink(player)
+
+	if resetError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: resetError.Error()})
+		return
+	}
+
+	recordAudit(r, &actor.ID, &player.ID, auditHousekeepingPasswordReset, nil)
+
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Println("Failed to send password reset email:", err)
+			}
+		}()
+
+		sendResetPasswordEmail(player, resetLink.Token)
+	}()
+
+	json.NewEncoder(w).Encode(DefaultApiResponse{Message: "A password reset email has been sent"})
+}
+
+func HousekeepingRevokeSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	actor, player, found := findHousekeepingPlayer(w, r)
+
+	if !found {
+		return
+	}
+
+	if err := revokeSessions(player.ID); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	recordAudit(r, &actor.ID, &player.ID, auditHousekeepingRevokeSessions, nil)
+
+	json.NewEncoder(w).Encode(DefaultApiResponse{Message: "All sessions have been revoked"})
+}
+
+func HousekeepingLinkedAccountsHandler(w http.ResponseWriter, r *http.Request) {
+	actor, player, found := findHousekeepingPlayer(w, r)
+
+	if !found {
+		return
+	}
+
+	var websiteData PlayerWebsiteData
+
+	var websiteError = database.Model(PlayerWebsiteData{}).
+		Where("player_id = ?", player.ID).
+		First(&websiteData).
+		Error
+
+	if websiteError != nil && !errors.Is(websiteError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: websiteError.Error()})
+		return
+	}
+
+	ips := []string{}
+
+	for _, ip := range []string{websiteData.InitialIp, websiteData.LastIp} {
+		if ip != "" {
+			ips = append(ips, ip)
+		}
+	}
+
+	var rows []housekeepingPlayerRow
+
+	if len(ips) > 0 {
+		var queryError = housekeepingPlayerQuery().
+			Where("player_website_data.initial_ip IN (?) OR player_website_data.last_ip IN (?)", ips, ips).
+			Where("players.id <> ?", player.ID).
+			Order("players.id ASC").
+			Limit(getEnvAsInt("HOUSEKEEPING_LINKED_LIMIT", 100)).
+			Scan(&rows).
+			Error
+
+		if queryError != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+			return
+		}
+	}
+
+	responses := make([]HousekeepingPlayerResponse, 0, len(rows))
+
+	for _, row := range rows {
+		responses = append(responses, row.response())
+	}
+
+	recordAudit(r, &actor.ID, &player.ID, auditHousekeepingLinkedAccounts, nil)
+
+	encodeResponse(w, r, responses)
+}
+
+func respondWithHousekeepingPlayer(w http.ResponseWriter, r *http.Request, player Player) {
+	var websiteData PlayerWebsiteData
+
+	var websiteError = database.Model(PlayerWebsiteData{}).
+		Where("player_id = ?", player.ID).
+		First(&websiteData).
+		Error
+
+	if websiteError != nil && !errors.Is(websiteError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: websiteError.Error()})
+		return
+	}
+
+	var bans []Ban
+
+	var bansError = database.Model(Ban{}).
+		Where("player_id = ?", player.ID).
+		Order("id DESC").
+		Find(&bans).
+		Error
+
+	if bansError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: bansError.Error()})
+		return
+	}
+
+	response := HousekeepingPlayerDetailResponse{
+		Player:    newPrivatePlayerResponse(player, includeSet{"data": true, "avatar_data": true, "roles": true}),
+		InitialIp: websiteData.InitialIp,
+		LastIp:    websiteData.LastIp,
+		Bans:      make([]BanResponse, 0, len(bans)),
+	}
+
+	if !websiteData.LastLogin.IsZero() {
+		response.LastLogin = &websiteData.LastLogin
+	}
+
+	for _, ban := range bans {
+		response.Bans = append(response.Bans, newBanResponse(ban))
+	}
+
+	encodeResponse(w, r, response)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type leaderboardRow struct {
+	Position         int64
+	Value            int64
+	PlayerId         int64
+	Username         string
+	FigureCode       string
+	Motto            string
+	IsOnline         int16
+	HideOnlineStatus *bool
+}
+
+var leaderboardMetrics = currencyColumns
+
+func LeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	metric := mux.Vars(r)["metric"]
+
+	if _, exists := leaderboardMetrics[metric]; !exists {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Unknown leaderboard"})
+		return
+	}
+
+	cursor, cursorError := decodeCursor(r.URL.Query().Get("cursor"))
+
+	if cursorError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid cursor"})
+		return
+	}
+
+	limit := parseLimit(r, 25, 100)
+
+	query := database.Table("leaderboard_entries").
+		Select("leaderboard_entries.position, leaderboard_entries.value, leaderboard_entries.player_id, "+
+			"players.username, player_avatar_data.figure_code, player_avatar_data.motto, "+
+			"player_data.is_online, player_privacy_settings.hide_online_status").
+		Joins("INNER JOIN players ON players.id = leaderboard_entries.player_id").
+		Joins("LEFT JOIN player_data ON player_data.player_id = players.id").
+		Joins("LEFT JOIN player_avatar_data ON player_avatar_data.player_id = players.id").
+		Joins("LEFT JOIN player_privacy_settings ON player_privacy_settings.player_id = players.id").
+		Where("leaderboard_entries.metric = ?", metric)
+
+	if cursor != nil {
+		query = query.Where("leaderboard_entries.position > ?", cursor.ID)
+	}
+
+	var rows []leaderboardRow
+
+	var queryError = query.
+		Order("leaderboard_entries.position ASC").
+		Limit(limit + 1).
+		Scan(&rows).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	nextCursor := ""
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		nextCursor = encodeCursor(pageCursor{ID: rows[len(rows)-1].Position})
+	}
+
+	entries := make([]LeaderboardEntryResponse, 0, len(rows))
+
+	for _, row := range rows {
+		entries = append(entries, LeaderboardEntryResponse{
+			Position: row.Position,
+			Value:    row.Value,
+			Player: PlayerCardResponse{
+				ID:         row.PlayerId,
+				Username:   row.Username,
+				FigureCode: row.FigureCode,
+				Motto:      row.Motto,
+				IsOnline:   row.IsOnline == 1 && (row.HideOnlineStatus == nil || !*row.HideOnlineStatus),
+			},
+		})
+	}
+
+	encodePage(w, r, entries, nextCursor)
+}
+
+func LeaderboardRankHandler(w http.ResponseWriter, r *http.Request) {
+	metric := mux.Vars(r)["metric"]
+	column, exists := leaderboardMetrics[metric]
+
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Unknown leaderboard"})
+		return
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	var values struct {
+		Value int64
+	}
+
+	var valueError = database.Table("player_data").
+		Select(fmt.Sprintf("%s AS value", column)).
+		Where("player_id = ?", player.ID).
+		Scan(&values).
+		Error
+
+	if valueError != nil && !errors.Is(valueError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: valueError.Error()})
+		return
+	}
+
+	response := LeaderboardRankResponse{Metric: metric, Value: values.Value}
+
+	var entry LeaderboardEntry
+
+	var entryError = database.Model(LeaderboardEntry{}).
+		Where("metric = ?", metric).
+		Where("player_id = ?", player.ID).
+		First(&entry).
+		Error
+
+	if entryError == nil {
+		response.Position = &entry.Position
+		response.GeneratedAt = &entry.GeneratedAt
+	} else if !errors.Is(entryError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: entryError.Error()})
+		return
+	}
+
+	encodeResponse(w, r, response)
+}
+
+func refreshLeaderboards() error {
+	for metric, column := range leaderboardMetrics {
+		if err := refreshLeaderboard(metric, column); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func refreshLeaderboard(metric string, column string) error {
+	excluded, excludedArgs := leaderboardExclusions()
+	size := getEnvAsInt("LEADERBOARD_SIZE", 1000)
+
+	return database.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("metric = ?", metric).Delete(LeaderboardEntry{}).Error; err != nil {
+			return err
+		}
+
+		insert := fmt.Sprintf("INSERT INTO leaderboard_entries (metric, position, player_id, value, generated_at) "+
+			"SELECT ?, ROW_NUMBER() OVER (ORDER BY player_data.%[1]s DESC, player_data.player_id ASC), "+
+			"player_data.player_id, player_data.%[1]s, ? "+
+			"FROM player_data WHERE %[2]s "+
+			"ORDER BY player_data.%[1]s DESC, player_data.player_id ASC LIMIT ?", column, excluded)
+
+		args := append([]interface{}{metric, time.Now().In(location)}, excludedArgs...)
+
+		return tx.Exec(insert, append(args, size)...).Error
+	})
+}
+
+func leaderboardExclusions() (string, []interface{}) {
+	exclusions := []string{
+		"player_data.player_id NOT IN (" +
+			"SELECT bans.player_id FROM bans " +
+			"WHERE bans.type = ? AND bans.lifted_at IS NULL " +
+			"AND (bans.is_permanent = 1 OR bans.expires_at > ?))",
+	}
+
+	args := []interface{}{banTypeAccount, time.Now().In(location)}
+
+	if getEnv("LEADERBOARD_EXCLUDE_STAFF", "true") == "true" {
+		exclusions = append(exclusions, "player_data.player_id NOT IN ("+
+			"SELECT player_role.player_id FROM player_role "+
+			"INNER JOIN roles ON roles.id = player_role.role_id "+
+			"WHERE roles.is_hidden = 0)")
+	}
+
+	return strings.Join(exclusions, " AND "), args
+}
+
+func startLeaderboardRefresher() {
+	interval := time.Duration(getEnvAsInt("LEADERBOARD_REFRESH_MINUTES", 10)) * time.Minute
+
+	go func() {
+		for {
+			if err := refreshLeaderboards(); err != nil {
+				log.Println("Failed to refresh leaderboards:", err)
+			}
+
+			time.Sleep(interval)
+		}
+	}()
+}
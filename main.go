@@ -19,8 +19,19 @@ func main() {
 	location, _ = time.LoadLocation(os.Getenv("TIMEZONE"))
 
 	loadDatabase()
+	migrateDatabase()
+
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
+
 	setupOauth()
 	setupMail()
+	startOnlinePeakTracker()
+	startLeaderboardRefresher()
+	startAuditPruner()
+	startAccountDeletionWorker()
 	registerRoutes()
 	serveHttp()
 }
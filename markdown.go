@@ -0,0 +1,176 @@
+package main
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var markdownImagePattern = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+var markdownLinkPattern = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+var markdownBoldPattern = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+var markdownItalicPattern = regexp.MustCompile(`\*([^*]+)\*`)
+var markdownCodePattern = regexp.MustCompile("`([^`]+)`")
+var markdownOrderedPattern = regexp.MustCompile(`^\d+\.\s+`)
+
+func renderMarkdown(source string) string {
+	var output strings.Builder
+	var paragraph []string
+	var listTag string
+	inCode := false
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			output.WriteString("<p>" + renderMarkdownInline(strings.Join(paragraph, " ")) + "</p>\n")
+			paragraph = nil
+		}
+	}
+
+	closeList := func() {
+		if listTag != "" {
+			output.WriteString("</" + listTag + ">\n")
+			listTag = ""
+		}
+	}
+
+	openList := func(tag string) {
+		if listTag != tag {
+			closeList()
+			output.WriteString("<" + tag + ">\n")
+			listTag = tag
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") {
+			flushParagraph()
+			closeList()
+
+			if inCode {
+				output.WriteString("</code></pre>\n")
+			} else {
+				output.WriteString("<pre><code>")
+			}
+
+			inCode = !inCode
+			continue
+		}
+
+		if inCode {
+			output.WriteString(html.EscapeString(line) + "\n")
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			flushParagraph()
+			closeList()
+		case trimmed == "---" || trimmed == "***":
+			flushParagraph()
+			closeList()
+			output.WriteString("<hr>\n")
+		case strings.HasPrefix(trimmed, "#"):
+			flushParagraph()
+			closeList()
+
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+
+			if level > 6 {
+				level = 6
+			}
+
+			tag := "h" + string(rune('0'+level))
+			output.WriteString("<" + tag + ">" + renderMarkdownInline(strings.TrimSpace(trimmed[level:])) + "</" + tag + ">\n")
+		case strings.HasPrefix(trimmed, ">"):
+			flushParagraph()
+			closeList()
+			output.WriteString("<blockquote>" + renderMarkdownInline(strings.TrimSpace(trimmed[1:])) + "</blockquote>\n")
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* "):
+			flushParagraph()
+			openList("ul")
+			output.WriteString("<li>" + renderMarkdownInline(strings.TrimSpace(trimmed[2:])) + "</li>\n")
+		case markdownOrderedPattern.MatchString(trimmed):
+			flushParagraph()
+			openList("ol")
+			output.WriteString("<li>" + renderMarkdownInline(markdownOrderedPattern.ReplaceAllString(trimmed, "")) + "</li>\n")
+		default:
+			closeList()
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+
+	if inCode {
+		output.WriteString("</code></pre>\n")
+	}
+
+	flushParagraph()
+	closeList()
+
+	return output.String()
+}
+
+func renderMarkdownEmphasis(text string) string {
+	text = markdownBoldPattern.ReplaceAllString(text, "<strong>$1</strong>")
+	return markdownItalicPattern.ReplaceAllString(text, "<em>$1</em>")
+}
+
+func renderMarkdownInline(text string) string {
+	var rendered []string
+
+	// Finished markup is swapped for a placeholder so later passes can't rewrite code or link targets
+	protect := func(markup string) string {
+		rendered = append(rendered, markup)
+		return "\x00" + strconv.Itoa(len(rendered)-1) + "\x00"
+	}
+
+	text = strings.ReplaceAll(text, "\x00", "")
+	text = markdownCodePattern.ReplaceAllStringFunc(text, func(match string) string {
+		return protect("<code>" + html.EscapeString(match[1:len(match)-1]) + "</code>")
+	})
+
+	text = html.EscapeString(text)
+
+	text = markdownImagePattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := markdownImagePattern.FindStringSubmatch(match)
+
+		if !isSafeMarkdownUrl(html.UnescapeString(parts[2])) {
+			return parts[1]
+		}
+
+		return protect(`<img src="` + parts[2] + `" alt="` + parts[1] + `">`)
+	})
+
+	text = markdownLinkPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := markdownLinkPattern.FindStringSubmatch(match)
+
+		if !isSafeMarkdownUrl(html.UnescapeString(parts[2])) {
+			return parts[1]
+		}
+
+		return protect(`<a href="` + parts[2] + `" rel="nofollow noopener">` + renderMarkdownEmphasis(parts[1]) + `</a>`)
+	})
+
+	text = renderMarkdownEmphasis(text)
+
+	for i := len(rendered) - 1; i >= 0; i-- {
+		text = strings.Replace(text, "\x00"+strconv.Itoa(i)+"\x00", rendered[i], 1)
+	}
+
+	return text
+}
+
+func isSafeMarkdownUrl(url string) bool {
+	lower := strings.ToLower(strings.TrimSpace(url))
+
+	if strings.HasPrefix(lower, "//") {
+		return false
+	}
+
+	return strings.HasPrefix(lower, "https://") ||
+		strings.HasPrefix(lower, "http://") ||
+		strings.HasPrefix(lower, "mailto:") ||
+		strings.HasPrefix(lower, "/")
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestIsSafeMarkdownUrl(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://example.com", true},
+		{"http://example.com/page", true},
+		{"HTTPS://EXAMPLE.COM", true},
+		{"mailto:staff@example.com", true},
+		{"/articles/welcome", true},
+		{"  https://example.com  ", true},
+		{"//evil.example", false},
+		{"javascript:alert(1)", false},
+		{"JavaScript:alert(1)", false},
+		{" javascript:alert(1)", false},
+		{"data:text/html;base64,PHNjcmlwdD4=", false},
+		{"vbscript:msgbox(1)", false},
+		{"relative/path", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if got := isSafeMarkdownUrl(test.url); got != test.want {
+			t.Errorf("isSafeMarkdownUrl(%q) = %v, want %v", test.url, got, test.want)
+		}
+	}
+}
+
+func TestRenderMarkdownInline(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"plain text", "hello", "hello"},
+		{"raw html is escaped", "<script>alert(1)</script>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{"ampersands are escaped", "fish & chips", "fish &amp; chips"},
+		{"bold and italic", "**bold** and *italic*", "<strong>bold</strong> and <em>italic</em>"},
+		{"safe link", "[site](https://example.com)", `<a href="https://example.com" rel="nofollow noopener">site</a>`},
+		{"javascript link", "[click](javascript:alert`1`)", "click"},
+		{"link text emphasis", "[**x**](https://example.com)", `<a href="https://example.com" rel="nofollow noopener"><strong>x</strong></a>`},
+		{"data link", "[click](data:text/html,hi)", "click"},
+		{"protocol relative link", "[click](//evil.example)", "click"},
+		{"quote in link target", `[x](https://example.com/"onmouseover="alert(1))`, `<a href="https://example.com/&#34;onmouseover=&#34;alert(1" rel="nofollow noopener">x</a>)`},
+		{"ampersand in link target", "[x](https://example.com/?a=1&b=2)", `<a href="https://example.com/?a=1&amp;b=2" rel="nofollow noopener">x</a>`},
+		{"html in link text", "[<b>x</b>](https://example.com)", `<a href="https://example.com" rel="nofollow noopener">&lt;b&gt;x&lt;/b&gt;</a>`},
+		{"emphasis in link target", "[x](https://example.com/*a*b)", `<a href="https://example.com/*a*b" rel="nofollow noopener">x</a>`},
+		{"safe image", "![alt](https://example.com/a.png)", `<img src="https://example.com/a.png" alt="alt">`},
+		{"unsafe image", "![alt](javascript:alert`1`)", "alt"},
+		{"quote in image alt", `![a"b](https://example.com/a.png)`, `<img src="https://example.com/a.png" alt="a&#34;b">`},
+		{"code span", "`<b>**x**</b>`", "<code>&lt;b&gt;**x**&lt;/b&gt;</code>"},
+		{"code span keeps links literal", "`[x](https://example.com)`", "<code>[x](https://example.com)</code>"},
+		{"forged placeholder", "\x000\x00`x`", "0<code>x</code>"},
+		{"code span in link text", "[`x`](https://example.com)", `<a href="https://example.com" rel="nofollow noopener"><code>x</code></a>`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := renderMarkdownInline(test.source); got != test.want {
+				t.Errorf("renderMarkdownInline(%q) = %q, want %q", test.source, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"paragraphs", "one\ntwo\n\nthree", "<p>one two</p>\n<p>three</p>\n"},
+		{"heading", "## Title", "<h2>Title</h2>\n"},
+		{"unordered list", "- a\n- b", "<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n"},
+		{"ordered list", "1. a\n2. b", "<ol>\n<li>a</li>\n<li>b</li>\n</ol>\n"},
+		{"blockquote", "> <i>quoted</i>", "<blockquote>&lt;i&gt;quoted&lt;/i&gt;</blockquote>\n"},
+		{"rule", "---", "<hr>\n"},
+		{"code block escapes html", "```\n<script>x</script>\n```", "<pre><code>&lt;script&gt;x&lt;/script&gt;\n</code></pre>\n"},
+		{"unterminated code block", "```\n**x**", "<pre><code>**x**\n</code></pre>\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := renderMarkdown(test.source); got != test.want {
+				t.Errorf("renderMarkdown(%q) = %q, want %q", test.source, got, test.want)
+			}
+		})
+	}
+}
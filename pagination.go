@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type pageCursor struct {
+	Value string `json:"v"`
+	ID    int64  `json:"id"`
+}
+
+type PageResponse struct {
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+func encodeCursor(cursor pageCursor) string {
+	encoded, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func decodeCursor(raw string) (*pageCursor, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor pageCursor
+
+	if err := json.Unmarshal(decoded, &cursor); err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
+
+func parseLimit(r *http.Request, defaultLimit int, maxLimit int) int {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	if err != nil || limit < 1 {
+		return defaultLimit
+	}
+
+	if limit > maxLimit {
+		return maxLimit
+	}
+
+	return limit
+}
+
+func encodePage(w http.ResponseWriter, r *http.Request, items interface{}, nextCursor string) {
+	filtered, err := applyFieldSet(r, items)
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(PageResponse{Data: filtered, NextCursor: nextCursor})
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
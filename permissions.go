@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+const (
+	permissionViewStatistics   = "hotel.statistics"
+	permissionManageCurrency   = "currency.manage"
+	permissionManageVouchers   = "vouchers.manage"
+	permissionWriteArticles    = "articles.write"
+	permissionModerateComments = "comments.moderate"
+	permissionManageBadges     = "badges.manage"
+	permissionManageBans       = "bans.manage"
+	permissionHousekeeping     = "housekeeping.players"
+	permissionViewAudit        = "audit.view"
+)
+
+func playerHasPermission(playerId int64, permission string) (bool, error) {
+	var count int
+
+	var queryError = database.Table("role_permissions").
+		Joins("INNER JOIN player_role ON player_role.role_id = role_permissions.role_id").
+		Where("player_role.player_id = ?", playerId).
+		Where("role_permissions.name = ?", permission).
+		Count(&count).
+		Error
+
+	return count > 0, queryError
+}
+
+func requirePermission(permission string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			player, playerError := getAuthenticatedPlayer(r)
+
+			if playerError != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+				return
+			}
+
+			allowed, permissionError := playerHasPermission(player.ID, permission)
+
+			if permissionError != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(DefaultApiResponse{Message: permissionError.Error()})
+				return
+			}
+
+			if !allowed {
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You don't have permission to do that"})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func withPermission(permission string, handler http.HandlerFunc) http.Handler {
+	return requirePermission(permission)(handler)
+}
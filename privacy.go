@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/jinzhu/gorm"
+	"net/http"
+)
+
+type privacySettingsRequest struct {
+	HideFromSearch   *bool   `json:"hide_from_search"`
+	HideOnlineStatus *bool   `json:"hide_online_status"`
+	GuestbookMode    *string `json:"guestbook_mode"`
+	HideRooms        *bool   `json:"hide_rooms"`
+}
+
+func loadPrivacySettings(playerId int64) (PlayerPrivacySettings, error) {
+	var settings PlayerPrivacySettings
+
+	var queryError = database.Model(PlayerPrivacySettings{}).
+		Where("player_id = ?", playerId).
+		First(&settings).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		return PlayerPrivacySettings{PlayerId: playerId}, nil
+	}
+
+	return settings, queryError
+}
+
+func GetPrivacySettingsHandler(w http.ResponseWriter, r *http.Request) {
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	settings, settingsError := loadPrivacySettings(player.ID)
+
+	if settingsError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: settingsError.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(newPrivacySettingsResponse(settings))
+}
+
+func UpdatePrivacySettingsHandler(w http.ResponseWriter, r *http.Request) {
+	var req privacySettingsRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	if req.GuestbookMode != nil && !isGuestbookMode(*req.GuestbookMode) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Guestbook mode must be one of open, friends or disabled"})
+		return
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	settings, settingsError := loadPrivacySettings(player.ID)
+
+	if settingsError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: settingsError.Error()})
+		return
+	}
+
+	if req.GuestbookMode != nil {
+		settings.GuestbookMode = *req.GuestbookMode
+	}
+
+	if req.HideFromSearch != nil {
+		settings.HideFromSearch = *req.HideFromSearch
+	}
+
+	if req.HideOnlineStatus != nil {
+		settings.HideOnlineStatus = *req.HideOnlineStatus
+	}
+
+	if req.HideRooms != nil {
+		settings.HideRooms = *req.HideRooms
+	}
+
+	if err := database.Save(&settings).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(newPrivacySettingsResponse(settings))
+}
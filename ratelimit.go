@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/go-oauth2/oauth2/v4"
+	"github.com/jinzhu/gorm"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type rateLimitKeyFunc func(r *http.Request) string
+
+type rateLimit struct {
+	name     string
+	capacity float64
+	period   time.Duration
+	key      rateLimitKeyFunc
+}
+
+type rateLimitResult struct {
+	allowed   bool
+	remaining float64
+}
+
+type rateLimitStore interface {
+	take(key string, limit rateLimit, now time.Time) (rateLimitResult, error)
+}
+
+func newRateLimit(name string, capacity int, period time.Duration, key rateLimitKeyFunc) rateLimit {
+	prefix := "RATE_LIMIT_" + strings.ToUpper(name)
+
+	return rateLimit{
+		name:     name,
+		capacity: float64(getEnvAsInt(prefix+"_CAPACITY", capacity)),
+		period:   time.Duration(getEnvAsInt(prefix+"_PERIOD_SECONDS", int(period.Seconds()))) * time.Second,
+		key:      key,
+	}
+}
+
+func (l rateLimit) refillRate() float64 {
+	return l.capacity / l.period.Seconds()
+}
+
+func (l rateLimit) refill(tokens float64, since time.Time, now time.Time) float64 {
+	return math.Min(l.capacity, tokens+now.Sub(since).Seconds()*l.refillRate())
+}
+
+func rateLimitByIp(r *http.Request) string {
+	return "ip:" + getUserIp(r)
+}
+
+func rateLimitByPlayer(r *http.Request) string {
+	if tokenInfo, ok := r.Context().Value("tokenInfo").(oauth2.TokenInfo); ok {
+		return "player:" + tokenInfo.GetUserID()
+	}
+
+	return rateLimitByIp(r)
+}
+
+func isRegisteredClient(r *http.Request, clientId string) bool {
+	if oauthServer == nil || clientId == "" {
+		return false
+	}
+
+	_, err := oauthServer.Manager.GetClient(r.Context(), clientId)
+	return err == nil
+}
+
+func rateLimitByClient(r *http.Request) string {
+	if tokenInfo, ok := r.Context().Value("tokenInfo").(oauth2.TokenInfo); ok {
+		return "client:" + tokenInfo.GetClientID()
+	}
+
+	clientId, _, ok := r.BasicAuth()
+
+	if !ok {
+		clientId = r.URL.Query().Get("client_id")
+	}
+
+	if isRegisteredClient(r, clientId) {
+		return "client:" + clientId
+	}
+
+	return rateLimitByIp(r)
+}
+
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	period    time.Duration
+}
+
+type memoryRateLimitStore struct {
+	mutex      sync.Mutex
+	buckets    map[string]*tokenBucket
+	lastPurged time.Time
+}
+
+func newMemoryRateLimitStore() *memoryRateLimitStore {
+	return &memoryRateLimitStore{buckets: map[string]*tokenBucket{}}
+}
+
+func (s *memoryRateLimitStore) take(key string, limit rateLimit, now time.Time) (rateLimitResult, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if now.Sub(s.lastPurged) > time.Minute {
+		for bucketKey, bucket := range s.buckets {
+			if now.Sub(bucket.updatedAt) > bucket.period {
+				delete(s.buckets, bucketKey)
+			}
+		}
+
+		s.lastPurged = now
+	}
+
+	bucket, exists := s.buckets[key]
+
+	if !exists {
+		bucket = &tokenBucket{tokens: limit.capacity, updatedAt: now, period: limit.period}
+		s.buckets[key] = bucket
+	}
+
+	bucket.tokens = limit.refill(bucket.tokens, bucket.updatedAt, now)
+	bucket.updatedAt = now
+
+	if bucket.tokens < 1 {
+		return rateLimitResult{allowed: false, remaining: bucket.tokens}, nil
+	}
+
+	bucket.tokens--
+
+	return rateLimitResult{allowed: true, remaining: bucket.tokens}, nil
+}
+
+type databaseRateLimitStore struct {
+	mutex      sync.Mutex
+	lastPurged time.Time
+}
+
+func (s *databaseRateLimitStore) purge(now time.Time) {
+	s.mutex.Lock()
+
+	if now.Sub(s.lastPurged) <= time.Minute {
+		s.mutex.Unlock()
+		return
+	}
+
+	s.lastPurged = now
+	s.mutex.Unlock()
+
+	if err := database.Where("full_at < ?", now).Delete(RateLimitBucket{}).Error; err != nil {
+		log.Println("Failed to prune rate limit buckets:", err)
+	}
+}
+
+func (s *databaseRateLimitStore) take(key string, limit rateLimit, now time.Time) (rateLimitResult, error) {
+	var result rateLimitResult
+
+	s.purge(now)
+
+	transactionError := database.Transaction(func(tx *gorm.DB) error {
+		var bucket RateLimitBucket
+
+		var insertError = tx.Exec("INSERT INTO rate_limit_buckets (`key`, tokens, updated_at, full_at) VALUES (?, ?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE `key` = `key`", key, limit.capacity, now, now).
+			Error
+
+		if insertError != nil {
+			return insertError
+		}
+
+		var queryError = tx.Set("gorm:query_option", "FOR UPDATE").
+			Where("`key` = ?", key).
+			First(&bucket).
+			Error
+
+		if queryError != nil {
+			return queryError
+		}
+
+		bucket.Tokens = limit.refill(bucket.Tokens, bucket.UpdatedAt, now)
+		result = rateLimitResult{allowed: bucket.Tokens >= 1, remaining: bucket.Tokens}
+
+		if result.allowed {
+			bucket.Tokens--
+			result.remaining = bucket.Tokens
+		}
+
+		fullAt := now.Add(time.Duration((limit.capacity - bucket.Tokens) / limit.refillRate() * float64(time.Second)))
+
+		return tx.Model(RateLimitBucket{}).
+			Where("`key` = ?", key).
+			Updates(map[string]interface{}{"tokens": bucket.Tokens, "updated_at": now, "full_at": fullAt}).
+			Error
+	})
+
+	return result, transactionError
+}
+
+var rateLimitStoreOnce sync.Once
+var rateLimits rateLimitStore
+
+func loadRateLimitStore() {
+	switch store := getEnv("RATE_LIMIT_STORE", "memory"); store {
+	case "memory":
+		rateLimits = newMemoryRateLimitStore()
+	case "database":
+		rateLimits = &databaseRateLimitStore{}
+	default:
+		log.Fatalln("Unknown rate limit store", store)
+	}
+}
+
+func getRateLimitStore() rateLimitStore {
+	rateLimitStoreOnce.Do(loadRateLimitStore)
+	return rateLimits
+}
+
+func rateLimitMiddleware(limit rateLimit) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if limit.capacity <= 0 || limit.period <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			result, err := getRateLimitStore().take(limit.name+":"+limit.key(r), limit, time.Now())
+
+			if err != nil {
+				log.Println("Failed to apply rate limit:", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			reset := (limit.capacity - result.remaining) / limit.refillRate()
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(int(limit.capacity)))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(int(math.Floor(result.remaining))))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(reset))))
+
+			if !result.allowed {
+				retryAfter := (1 - result.remaining) / limit.refillRate()
+
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter))))
+				w.WriteHeader(http.StatusTooManyRequests)
+				json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You're doing too much, slow down!"})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func withRateLimit(limit rateLimit, handler http.HandlerFunc) http.Handler {
+	return rateLimitMiddleware(limit)(handler)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimitRefill(t *testing.T) {
+	limit := rateLimit{capacity: 10, period: 10 * time.Second}
+	start := time.Now()
+
+	tests := []struct {
+		name    string
+		tokens  float64
+		elapsed time.Duration
+		want    float64
+	}{
+		{"no time passed", 2, 0, 2},
+		{"partial refill", 0, 3 * time.Second, 3},
+		{"fractional refill", 0, 500 * time.Millisecond, 0.5},
+		{"capped at capacity", 8, 5 * time.Second, 10},
+		{"empty bucket fully refilled", 0, time.Hour, 10},
+	}
+
+	for _, test := range tests {
+		if got := limit.refill(test.tokens, start, start.Add(test.elapsed)); got != test.want {
+			t.Errorf("%s: refill() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMemoryRateLimitStoreTake(t *testing.T) {
+	store := newMemoryRateLimitStore()
+	limit := rateLimit{name: "test", capacity: 2, period: 2 * time.Second}
+	start := time.Now()
+
+	steps := []struct {
+		key       string
+		at        time.Duration
+		allowed   bool
+		remaining float64
+	}{
+		{"a", 0, true, 1},
+		{"a", 0, true, 0},
+		{"a", 0, false, 0},
+		{"b", 0, true, 1},
+		{"a", 500 * time.Millisecond, false, 0.5},
+		{"a", time.Second, true, 0},
+		{"a", 10 * time.Second, true, 1},
+	}
+
+	for i, step := range steps {
+		result, err := store.take(step.key, limit, start.Add(step.at))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.allowed != step.allowed || result.remaining != step.remaining {
+			t.Errorf("step %d: take(%q) = %+v, want allowed=%v remaining=%v", i, step.key, result, step.allowed, step.remaining)
+		}
+	}
+
+	if _, err := store.take("c", limit, start.Add(5*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(store.buckets) != 1 {
+		t.Errorf("idle buckets weren't purged, %d remain", len(store.buckets))
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	rateLimitStoreOnce.Do(func() {})
+	previous := rateLimits
+	rateLimits = newMemoryRateLimitStore()
+	t.Cleanup(func() { rateLimits = previous })
+
+	limit := rateLimit{name: "middleware", capacity: 1, period: time.Minute, key: rateLimitByIp}
+	handler := withRateLimit(limit, func(w http.ResponseWriter, r *http.Request) {})
+
+	first := httptest.NewRecorder()
+	handler.ServeHTTP(first, httptest.NewRequest("GET", "/", nil))
+
+	if first.Code != http.StatusOK || first.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("first request: status %d, remaining %q", first.Code, first.Header().Get("RateLimit-Remaining"))
+	}
+
+	second := httptest.NewRecorder()
+	handler.ServeHTTP(second, httptest.NewRequest("GET", "/", nil))
+
+	if second.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: status %d, want %d", second.Code, http.StatusTooManyRequests)
+	}
+
+	if retryAfter := second.Header().Get("Retry-After"); retryAfter != "60" {
+		t.Errorf("Retry-After = %q, want %q", retryAfter, "60")
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/jinzhu/gorm"
+	"log"
+	"math/bits"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type captchaVerifier interface {
+	verify(token string, ip string) (bool, error)
+}
+
+type disabledCaptchaVerifier struct{}
+
+func (disabledCaptchaVerifier) verify(token string, ip string) (bool, error) {
+	return true, nil
+}
+
+var captchaOnce sync.Once
+var registrationCaptcha captchaVerifier
+
+func loadCaptchaVerifier() {
+	switch provider := getEnv("CAPTCHA_PROVIDER", ""); provider {
+	case "", "none":
+		registrationCaptcha = disabledCaptchaVerifier{}
+	default:
+		log.Fatalln("Unknown captcha provider", provider)
+	}
+}
+
+func getCaptchaVerifier() captchaVerifier {
+	captchaOnce.Do(loadCaptchaVerifier)
+	return registrationCaptcha
+}
+
+var disposableDomainsOnce sync.Once
+var disposableDomains map[string]bool
+
+func loadDisposableDomains() {
+	disposableDomains = map[string]bool{}
+
+	path := os.Getenv("DISPOSABLE_EMAIL_DOMAINS_FILE")
+
+	if path == "" {
+		return
+	}
+
+	file, err := os.Open(path)
+
+	if err != nil {
+		log.Println("Failed to load disposable email domains:", err)
+		return
+	}
+
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		if domain := strings.ToLower(strings.TrimSpace(scanner.Text())); domain != "" && !strings.HasPrefix(domain, "#") {
+			disposableDomains[domain] = true
+		}
+	}
+}
+
+func isDisposableEmail(email string) bool {
+	disposableDomainsOnce.Do(loadDisposableDomains)
+
+	at := strings.LastIndex(email, "@")
+
+	if at == -1 {
+		return false
+	}
+
+	domain := strings.ToLower(email[at+1:])
+
+	for domain != "" {
+		if disposableDomains[domain] {
+			return true
+		}
+
+		dot := strings.Index(domain, ".")
+
+		if dot == -1 {
+			break
+		}
+
+		domain = domain[dot+1:]
+	}
+
+	return false
+}
+
+type challengeStore interface {
+	issue(challenge string, difficulty int, expiresAt time.Time) error
+	redeem(challenge string, now time.Time) (int, bool, error)
+}
+
+type memoryChallengeStore struct {
+	cache *memoryCache
+}
+
+func (s memoryChallengeStore) issue(challenge string, difficulty int, expiresAt time.Time) error {
+	s.cache.purgeExpired()
+	s.cache.set(challenge, difficulty, time.Until(expiresAt))
+	return nil
+}
+
+func (s memoryChallengeStore) redeem(challenge string, now time.Time) (int, bool, error) {
+	difficulty, issued := s.cache.take(challenge)
+
+	if !issued {
+		return 0, false, nil
+	}
+
+	return difficulty.(int), true, nil
+}
+
+type databaseChallengeStore struct{}
+
+func (databaseChallengeStore) issue(challenge string, difficulty int, expiresAt time.Time) error {
+	if err := database.Where("expires_at < ?", time.Now().In(location)).Delete(RegistrationChallenge{}).Error; err != nil {
+		log.Println("Failed to prune registration challenges:", err)
+	}
+
+	return database.Create(&RegistrationChallenge{Challenge: challenge, Difficulty: difficulty, ExpiresAt: expiresAt}).Error
+}
+
+func (databaseChallengeStore) redeem(challenge string, now time.Time) (int, bool, error) {
+	var stored RegistrationChallenge
+
+	var queryError = database.Model(RegistrationChallenge{}).
+		Where("challenge = ?", challenge).
+		Where("expires_at > ?", now).
+		First(&stored).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		return 0, false, nil
+	}
+
+	if queryError != nil {
+		return 0, false, queryError
+	}
+
+	deleted := database.Where("challenge = ?", challenge).Delete(RegistrationChallenge{})
+
+	if deleted.Error != nil {
+		return 0, false, deleted.Error
+	}
+
+	return stored.Difficulty, deleted.RowsAffected == 1, nil
+}
+
+var challengeStoreOnce sync.Once
+var registrationChallenges challengeStore
+
+func loadChallengeStore() {
+	switch store := getEnv("REGISTRATION_CHALLENGE_STORE", getEnv("RATE_LIMIT_STORE", "memory")); store {
+	case "memory":
+		registrationChallenges = memoryChallengeStore{cache: newMemoryCache()}
+	case "database":
+		registrationChallenges = databaseChallengeStore{}
+	default:
+		log.Fatalln("Unknown registration challenge store", store)
+	}
+}
+
+func getChallengeStore() challengeStore {
+	challengeStoreOnce.Do(loadChallengeStore)
+	return registrationChallenges
+}
+
+func registrationDifficulty() int {
+	return getEnvAsInt("REGISTRATION_POW_DIFFICULTY", 0)
+}
+
+func isChallengeSolved(challenge string, solution string, difficulty int) bool {
+	hash := sha256.Sum256([]byte(challenge + ":" + solution))
+	zeroes := 0
+
+	for _, b := range hash {
+		if b != 0 {
+			zeroes += bits.LeadingZeros8(b)
+			break
+		}
+
+		zeroes += 8
+	}
+
+	return zeroes >= difficulty
+}
+
+func RegistrationChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	nonce := make([]byte, 16)
+
+	if _, err := rand.Read(nonce); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	ttl := time.Duration(getEnvAsInt("REGISTRATION_CHALLENGE_SECONDS", 300)) * time.Second
+	challenge := hex.EncodeToString(nonce)
+	difficulty := registrationDifficulty()
+	expiresAt := time.Now().In(location).Add(ttl)
+
+	if err := getChallengeStore().issue(challenge, difficulty, expiresAt); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(RegistrationChallengeResponse{
+		Challenge:  challenge,
+		Difficulty: difficulty,
+		ExpiresAt:  expiresAt,
+	})
+}
+
+func rejectAbusiveRegistration(w http.ResponseWriter, r *http.Request, req PlayerCreateRequest) bool {
+	if req.Website != "" || req.PhoneNumber != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return true
+	}
+
+	if registrationDifficulty() > 0 {
+		difficulty, issued, challengeError := getChallengeStore().redeem(req.Challenge, time.Now().In(location))
+
+		if challengeError != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: challengeError.Error()})
+			return true
+		}
+
+		if !issued || !isChallengeSolved(req.Challenge, req.Solution, difficulty) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The registration challenge is invalid or has expired"})
+			return true
+		}
+	}
+
+	verified, captchaError := getCaptchaVerifier().verify(req.CaptchaToken, getUserIp(r))
+
+	if captchaError != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "We couldn't verify the captcha, try again soon"})
+		return true
+	}
+
+	if !verified {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Please complete the captcha"})
+		return true
+	}
+
+	if isDisposableEmail(req.Email) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Please provide a real email address"})
+		return true
+	}
+
+	return false
+}
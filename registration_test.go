@@ -0,0 +1,158 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type stubCaptchaVerifier struct {
+	expected string
+}
+
+func (v stubCaptchaVerifier) verify(token string, ip string) (bool, error) {
+	return token != "" && token == v.expected, nil
+}
+
+func useStubCaptcha(t *testing.T, expected string) {
+	captchaOnce.Do(func() {})
+	previous := registrationCaptcha
+	registrationCaptcha = stubCaptchaVerifier{expected: expected}
+
+	t.Cleanup(func() { registrationCaptcha = previous })
+}
+
+func useDisposableDomains(t *testing.T, domains ...string) {
+	disposableDomainsOnce.Do(func() {})
+	previous := disposableDomains
+	disposableDomains = map[string]bool{}
+
+	for _, domain := range domains {
+		disposableDomains[domain] = true
+	}
+
+	t.Cleanup(func() { disposableDomains = previous })
+}
+
+func solveChallenge(challenge string, difficulty int) string {
+	for nonce := 0; ; nonce++ {
+		if solution := strconv.Itoa(nonce); isChallengeSolved(challenge, solution, difficulty) {
+			return solution
+		}
+	}
+}
+
+func TestIsChallengeSolved(t *testing.T) {
+	solution := solveChallenge("challenge", 12)
+
+	tests := []struct {
+		name       string
+		solution   string
+		difficulty int
+		want       bool
+	}{
+		{"no work required", "anything", 0, true},
+		{"solved at requested difficulty", solution, 12, true},
+		{"solved below requested difficulty", solution, 8, true},
+		{"unsolvable difficulty", solution, 256, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isChallengeSolved("challenge", test.solution, test.difficulty); got != test.want {
+				t.Errorf("isChallengeSolved(%q, %d) = %v, want %v", test.solution, test.difficulty, got, test.want)
+			}
+		})
+	}
+}
+
+func TestIsDisposableEmail(t *testing.T) {
+	useDisposableDomains(t, "mailinator.com")
+
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"player@mailinator.com", true},
+		{"player@MAILINATOR.com", true},
+		{"player@eu.mailinator.com", true},
+		{"player@notmailinator.com", false},
+		{"player@mailinator.com.example", false},
+		{"player@example.com", false},
+		{"not-an-email", false},
+	}
+
+	for _, test := range tests {
+		if got := isDisposableEmail(test.email); got != test.want {
+			t.Errorf("isDisposableEmail(%q) = %v, want %v", test.email, got, test.want)
+		}
+	}
+}
+
+func TestRejectAbusiveRegistration(t *testing.T) {
+	location = time.UTC
+	t.Setenv("REGISTRATION_POW_DIFFICULTY", "0")
+	useStubCaptcha(t, "valid-token")
+	useDisposableDomains(t, "mailinator.com")
+
+	valid := PlayerCreateRequest{Email: "player@example.com", CaptchaToken: "valid-token"}
+
+	tests := []struct {
+		name   string
+		modify func(req *PlayerCreateRequest)
+		status int
+	}{
+		{"valid registration", func(req *PlayerCreateRequest) {}, 0},
+		{"website honeypot", func(req *PlayerCreateRequest) { req.Website = "http://spam.example" }, http.StatusBadRequest},
+		{"phone honeypot", func(req *PlayerCreateRequest) { req.PhoneNumber = "555" }, http.StatusBadRequest},
+		{"missing captcha", func(req *PlayerCreateRequest) { req.CaptchaToken = "" }, http.StatusBadRequest},
+		{"wrong captcha", func(req *PlayerCreateRequest) { req.CaptchaToken = "forged" }, http.StatusBadRequest},
+		{"disposable email", func(req *PlayerCreateRequest) { req.Email = "player@mailinator.com" }, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := valid
+			test.modify(&req)
+
+			recorder := httptest.NewRecorder()
+			rejected := rejectAbusiveRegistration(recorder, httptest.NewRequest("POST", "/auth/create", nil), req)
+
+			if rejected != (test.status != 0) {
+				t.Fatalf("rejected = %v, want %v", rejected, test.status != 0)
+			}
+
+			if rejected && recorder.Code != test.status {
+				t.Errorf("status = %d, want %d", recorder.Code, test.status)
+			}
+		})
+	}
+}
+
+func TestRegistrationChallengeIsSingleUse(t *testing.T) {
+	location = time.UTC
+	t.Setenv("REGISTRATION_POW_DIFFICULTY", "8")
+	useStubCaptcha(t, "valid-token")
+	useDisposableDomains(t)
+
+	if err := getChallengeStore().issue("single-use", 8, time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	req := PlayerCreateRequest{
+		Email:        "player@example.com",
+		CaptchaToken: "valid-token",
+		Challenge:    "single-use",
+		Solution:     solveChallenge("single-use", 8),
+	}
+
+	if rejectAbusiveRegistration(httptest.NewRecorder(), httptest.NewRequest("POST", "/auth/create", nil), req) {
+		t.Fatal("a solved challenge was rejected")
+	}
+
+	if !rejectAbusiveRegistration(httptest.NewRecorder(), httptest.NewRequest("POST", "/auth/create", nil), req) {
+		t.Fatal("a challenge was accepted twice")
+	}
+}
@@ -0,0 +1,808 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jinzhu/gorm"
+	"strings"
+	"time"
+)
+
+var playerIncludes = []string{"data", "avatar_data", "roles", "badges", "mutual_friends", "guestbook", "rooms", "groups"}
+var defaultPlayerIncludes = []string{"data", "avatar_data"}
+var defaultProfileIncludes = []string{"data", "avatar_data", "badges", "mutual_friends"}
+
+type PlayerResponse struct {
+	ID            int64                     `json:"id"`
+	Username      string                    `json:"username"`
+	CreatedAt     time.Time                 `json:"created_at"`
+	Data          *PublicPlayerDataResponse `json:"data,omitempty"`
+	AvatarData    *PlayerAvatarDataResponse `json:"avatar_data,omitempty"`
+	Roles         []RoleSummaryResponse     `json:"roles,omitempty"`
+	Badges        []BadgeResponse           `json:"badges,omitempty"`
+	MutualFriends []PlayerCardResponse      `json:"mutual_friends,omitempty"`
+	Guestbook     []GuestbookEntryResponse  `json:"guestbook,omitempty"`
+	Rooms         []RoomResponse            `json:"rooms,omitempty"`
+	Groups        []GroupResponse           `json:"groups,omitempty"`
+}
+
+type PrivatePlayerResponse struct {
+	ID         int64                     `json:"id"`
+	Username   string                    `json:"username"`
+	Email      string                    `json:"email"`
+	CreatedAt  time.Time                 `json:"created_at"`
+	Data       *PlayerDataResponse       `json:"data,omitempty"`
+	AvatarData *PlayerAvatarDataResponse `json:"avatar_data,omitempty"`
+	Roles      []RoleSummaryResponse     `json:"roles,omitempty"`
+	Badges     []BadgeResponse           `json:"badges,omitempty"`
+}
+
+type PlayerDataResponse struct {
+	CreditBalance   int64     `json:"credit_balance"`
+	PixelBalance    int64     `json:"pixel_balance"`
+	SeasonalBalance int64     `json:"seasonal_balance"`
+	GotwPoints      int64     `json:"gotw_points"`
+	IsOnline        bool      `json:"is_online"`
+	LastOnline      time.Time `json:"last_online"`
+}
+
+type PublicPlayerDataResponse struct {
+	IsOnline   bool       `json:"is_online"`
+	LastOnline *time.Time `json:"last_online"`
+}
+
+type PlayerAvatarDataResponse struct {
+	FigureCode   string `json:"figure_code"`
+	Motto        string `json:"motto"`
+	Gender       string `json:"gender"`
+	ChatBubbleId int32  `json:"chat_bubble_id"`
+}
+
+type PlayerCardResponse struct {
+	ID         int64           `json:"id"`
+	Username   string          `json:"username"`
+	FigureCode string          `json:"figure_code"`
+	Motto      string          `json:"motto"`
+	IsOnline   bool            `json:"is_online"`
+	Badges     []BadgeResponse `json:"badges,omitempty"`
+}
+
+type RoleSummaryResponse struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type RoleResponse struct {
+	ID      int64                `json:"id"`
+	Name    string               `json:"name"`
+	Players []PlayerCardResponse `json:"players"`
+}
+
+type SsoTokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type PasswordResetLinkResponse struct {
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type StaffRoleResponse struct {
+	ID          int64                `json:"id"`
+	Name        string               `json:"name"`
+	BadgeCode   string               `json:"badge_code"`
+	Colour      string               `json:"colour"`
+	Description string               `json:"description"`
+	Players     []PlayerCardResponse `json:"players"`
+	Children    []StaffRoleResponse  `json:"children"`
+}
+
+type PrivacySettingsResponse struct {
+	HideFromSearch   bool   `json:"hide_from_search"`
+	HideOnlineStatus bool   `json:"hide_online_status"`
+	GuestbookMode    string `json:"guestbook_mode"`
+	HideRooms        bool   `json:"hide_rooms"`
+}
+
+type HotelStatsResponse struct {
+	UsersOnline       int64                `json:"users_online"`
+	RegisteredPlayers int64                `json:"registered_players"`
+	PeakOnlineToday   int64                `json:"peak_online_today"`
+	PeakOnlineAllTime int64                `json:"peak_online_all_time"`
+	NewestMembers     []PlayerCardResponse `json:"newest_members"`
+}
+
+type DailyCountResponse struct {
+	Day   string `json:"day"`
+	Count int64  `json:"count"`
+}
+
+type HotelStatsHistoryResponse struct {
+	Registrations []DailyCountResponse `json:"registrations"`
+	Logins        []DailyCountResponse `json:"logins"`
+}
+
+type LeaderboardEntryResponse struct {
+	Position int64              `json:"position"`
+	Value    int64              `json:"value"`
+	Player   PlayerCardResponse `json:"player"`
+}
+
+type LeaderboardRankResponse struct {
+	Metric      string     `json:"metric"`
+	Position    *int64     `json:"position"`
+	Value       int64      `json:"value"`
+	GeneratedAt *time.Time `json:"generated_at"`
+}
+
+type CurrencyTransactionResponse struct {
+	ID           int64     `json:"id"`
+	Currency     string    `json:"currency"`
+	Amount       int64     `json:"amount"`
+	BalanceAfter int64     `json:"balance_after"`
+	Reason       string    `json:"reason"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type VoucherRewardResponse struct {
+	Type            string `json:"type"`
+	Currency        string `json:"currency,omitempty"`
+	Amount          int64  `json:"amount,omitempty"`
+	BadgeCode       string `json:"badge_code,omitempty"`
+	FurnitureItemId int64  `json:"furniture_item_id,omitempty"`
+}
+
+type VoucherResponse struct {
+	ID             int64                   `json:"id"`
+	Code           string                  `json:"code"`
+	MaxUses        int                     `json:"max_uses"`
+	UsesCount      int                     `json:"uses_count"`
+	PerPlayerLimit int                     `json:"per_player_limit"`
+	ExpiresAt      *time.Time              `json:"expires_at"`
+	CreatedAt      time.Time               `json:"created_at"`
+	Rewards        []VoucherRewardResponse `json:"rewards"`
+}
+
+type VoucherRedemptionResponse struct {
+	ID         int64     `json:"id"`
+	PlayerId   int64     `json:"player_id"`
+	Ip         string    `json:"ip"`
+	RedeemedAt time.Time `json:"redeemed_at"`
+}
+
+type VoucherRedeemedResponse struct {
+	Code    string                  `json:"code"`
+	Rewards []VoucherRewardResponse `json:"rewards"`
+}
+
+type DailyRewardAmountResponse struct {
+	Currency string `json:"currency"`
+	Amount   int64  `json:"amount"`
+}
+
+type DailyRewardResponse struct {
+	CurrentStreak int                           `json:"current_streak"`
+	LongestStreak int                           `json:"longest_streak"`
+	CalendarDay   int                           `json:"calendar_day"`
+	ClaimedToday  bool                          `json:"claimed_today"`
+	Today         []DailyRewardAmountResponse   `json:"today"`
+	Calendar      [][]DailyRewardAmountResponse `json:"calendar"`
+}
+
+type ArticleSummaryResponse struct {
+	ID             int64              `json:"id"`
+	Title          string             `json:"title"`
+	Slug           string             `json:"slug"`
+	Summary        string             `json:"summary"`
+	HeaderImageUrl string             `json:"header_image_url"`
+	Author         PlayerCardResponse `json:"author"`
+	PublishedAt    *time.Time         `json:"published_at"`
+}
+
+type ArticleResponse struct {
+	ID             int64              `json:"id"`
+	Title          string             `json:"title"`
+	Slug           string             `json:"slug"`
+	Summary        string             `json:"summary"`
+	BodyHtml       string             `json:"body_html"`
+	HeaderImageUrl string             `json:"header_image_url"`
+	Author         PlayerCardResponse `json:"author"`
+	PublishedAt    *time.Time         `json:"published_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+	Reactions      map[string]int64   `json:"reactions,omitempty"`
+}
+
+type AdminArticleResponse struct {
+	ArticleResponse
+	Body         string `json:"body"`
+	Status       string `json:"status"`
+	PreviewToken string `json:"preview_token"`
+}
+
+type CommentResponse struct {
+	ID        int64              `json:"id"`
+	ParentId  *int64             `json:"parent_id"`
+	Author    PlayerCardResponse `json:"author"`
+	Body      string             `json:"body"`
+	IsHidden  bool               `json:"is_hidden,omitempty"`
+	Reactions map[string]int64   `json:"reactions"`
+	CreatedAt time.Time          `json:"created_at"`
+	EditedAt  *time.Time         `json:"edited_at"`
+	Replies   []CommentResponse  `json:"replies,omitempty"`
+}
+
+type ReactionsResponse struct {
+	Reactions map[string]int64 `json:"reactions"`
+}
+
+type BadgeResponse struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ImageUrl    string `json:"image_url"`
+}
+
+type PlayerBadgeResponse struct {
+	BadgeResponse
+	Slot      int       `json:"slot"`
+	AwardedAt time.Time `json:"awarded_at"`
+}
+
+type FriendRequestResponse struct {
+	ID        int64              `json:"id"`
+	Player    PlayerCardResponse `json:"player"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type GuestbookEntryResponse struct {
+	ID        int64              `json:"id"`
+	Author    PlayerCardResponse `json:"author"`
+	Body      string             `json:"body"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type RoomResponse struct {
+	ID           int64              `json:"id"`
+	Name         string             `json:"name"`
+	Description  string             `json:"description"`
+	Owner        PlayerCardResponse `json:"owner"`
+	UsersNow     int                `json:"users_now"`
+	MaxUsers     int                `json:"max_users"`
+	Tags         []string           `json:"tags"`
+	ThumbnailUrl string             `json:"thumbnail_url"`
+}
+
+type GroupResponse struct {
+	ID          int64              `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	BadgeCode   string             `json:"badge_code"`
+	BadgeUrl    string             `json:"badge_url"`
+	Owner       PlayerCardResponse `json:"owner"`
+	JoinMode    string             `json:"join_mode"`
+	MemberCount int64              `json:"member_count"`
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+type GroupMemberResponse struct {
+	Player   PlayerCardResponse `json:"player"`
+	Rank     string             `json:"rank"`
+	JoinedAt time.Time          `json:"joined_at"`
+}
+
+type GroupJoinRequestResponse struct {
+	Player      PlayerCardResponse `json:"player"`
+	RequestedAt time.Time          `json:"requested_at"`
+}
+
+type BanInfoResponse struct {
+	Type        string     `json:"type"`
+	Reason      string     `json:"reason"`
+	IsPermanent bool       `json:"is_permanent"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+type BannedResponse struct {
+	DefaultApiResponse
+	Ban BanInfoResponse `json:"ban"`
+}
+
+type BanResponse struct {
+	ID          int64      `json:"id"`
+	Type        string     `json:"type"`
+	Value       string     `json:"value"`
+	PlayerId    *int64     `json:"player_id"`
+	Reason      string     `json:"reason"`
+	IssuerId    int64      `json:"issuer_id"`
+	IsPermanent bool       `json:"is_permanent"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LiftedAt    *time.Time `json:"lifted_at"`
+	LiftedById  *int64     `json:"lifted_by_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type HousekeepingPlayerResponse struct {
+	ID        int64      `json:"id"`
+	Username  string     `json:"username"`
+	Email     string     `json:"email"`
+	CreatedAt time.Time  `json:"created_at"`
+	InitialIp string     `json:"initial_ip"`
+	LastIp    string     `json:"last_ip"`
+	LastLogin *time.Time `json:"last_login"`
+}
+
+type HousekeepingPlayerDetailResponse struct {
+	Player    PrivatePlayerResponse `json:"player"`
+	InitialIp string                `json:"initial_ip"`
+	LastIp    string                `json:"last_ip"`
+	LastLogin *time.Time            `json:"last_login"`
+	Bans      []BanResponse         `json:"bans"`
+}
+
+type AuditEventResponse struct {
+	ID             int64           `json:"id"`
+	ActorId        *int64          `json:"actor_id"`
+	TargetPlayerId *int64          `json:"target_player_id"`
+	Action         string          `json:"action"`
+	Ip             string          `json:"ip"`
+	UserAgent      string          `json:"user_agent"`
+	Metadata       json.RawMessage `json:"metadata"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type SecurityActivityResponse struct {
+	Action    string    `json:"action"`
+	Ip        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type NameChangeResponse struct {
+	OldUsername string    `json:"old_username"`
+	NewUsername string    `json:"new_username"`
+	Cost        int64     `json:"cost"`
+	ChangedById *int64    `json:"changed_by_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ProfileRedirectResponse struct {
+	Message  string `json:"message"`
+	Username string `json:"username"`
+}
+
+type AccountDeletionResponse struct {
+	Status       string     `json:"status"`
+	RequestedAt  time.Time  `json:"requested_at"`
+	ScheduledFor *time.Time `json:"scheduled_for"`
+}
+
+type RegistrationChallengeResponse struct {
+	Challenge  string    `json:"challenge"`
+	Difficulty int       `json:"difficulty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func preloadPlayerIncludes(query *gorm.DB, includes includeSet) *gorm.DB {
+	if includes["data"] {
+		query = query.Preload("Data")
+	}
+
+	if includes["avatar_data"] {
+		query = query.Preload("AvatarData")
+	}
+
+	if includes["roles"] {
+		query = query.Preload("Roles")
+	}
+
+	if includes["badges"] {
+		query = preloadEquippedBadges(query, "Badges")
+	}
+
+	return query
+}
+
+func preloadEquippedBadges(query *gorm.DB, association string) *gorm.DB {
+	return query.
+		Preload(association, func(db *gorm.DB) *gorm.DB {
+			return db.Where("slot > 0").Order("slot ASC")
+		}).
+		Preload(association + ".Badge")
+}
+
+func newPlayerResponse(player Player, includes includeSet) PlayerResponse {
+	response := PlayerResponse{
+		ID:        player.ID,
+		Username:  player.Username,
+		CreatedAt: player.CreatedAt,
+	}
+
+	if includes["data"] {
+		response.Data = &PublicPlayerDataResponse{
+			IsOnline:   player.Data.IsOnline == 1,
+			LastOnline: &player.Data.LastOnline,
+		}
+	}
+
+	if includes["avatar_data"] {
+		avatarData := newPlayerAvatarDataResponse(player.AvatarData)
+		response.AvatarData = &avatarData
+	}
+
+	if includes["roles"] {
+		response.Roles = newRoleSummaryResponses(player.Roles)
+	}
+
+	if includes["badges"] {
+		response.Badges = newEquippedBadgeResponses(player.Badges)
+	}
+
+	return response
+}
+
+func newPrivatePlayerResponse(player Player, includes includeSet) PrivatePlayerResponse {
+	response := PrivatePlayerResponse{
+		ID:        player.ID,
+		Username:  player.Username,
+		Email:     player.Email,
+		CreatedAt: player.CreatedAt,
+	}
+
+	if includes["data"] {
+		response.Data = &PlayerDataResponse{
+			CreditBalance:   player.Data.CreditBalance,
+			PixelBalance:    player.Data.PixelBalance,
+			SeasonalBalance: player.Data.SeasonalBalance,
+			GotwPoints:      player.Data.GotwPoints,
+			IsOnline:        player.Data.IsOnline == 1,
+			LastOnline:      player.Data.LastOnline,
+		}
+	}
+
+	if includes["avatar_data"] {
+		avatarData := newPlayerAvatarDataResponse(player.AvatarData)
+		response.AvatarData = &avatarData
+	}
+
+	if includes["roles"] {
+		response.Roles = newRoleSummaryResponses(player.Roles)
+	}
+
+	if includes["badges"] {
+		response.Badges = newEquippedBadgeResponses(player.Badges)
+	}
+
+	return response
+}
+
+func newPlayerAvatarDataResponse(avatarData PlayerAvatarData) PlayerAvatarDataResponse {
+	return PlayerAvatarDataResponse{
+		FigureCode:   avatarData.FigureCode,
+		Motto:        avatarData.Motto,
+		Gender:       avatarData.Gender,
+		ChatBubbleId: avatarData.ChatBubbleId,
+	}
+}
+
+func newPlayerCardResponse(player Player) PlayerCardResponse {
+	return PlayerCardResponse{
+		ID:         player.ID,
+		Username:   player.Username,
+		FigureCode: player.AvatarData.FigureCode,
+		Motto:      player.AvatarData.Motto,
+		IsOnline:   player.Data.IsOnline == 1 && (player.PrivacySettings == nil || !player.PrivacySettings.HideOnlineStatus),
+		Badges:     newEquippedBadgeResponses(player.Badges),
+	}
+}
+
+func newRoleSummaryResponses(roles []Role) []RoleSummaryResponse {
+	responses := make([]RoleSummaryResponse, 0, len(roles))
+
+	for _, role := range roles {
+		responses = append(responses, RoleSummaryResponse{ID: role.ID, Name: role.Name})
+	}
+
+	return responses
+}
+
+func newRoleResponse(role Role) RoleResponse {
+	players := make([]PlayerCardResponse, 0, len(role.Players))
+
+	for _, player := range role.Players {
+		players = append(players, newPlayerCardResponse(player))
+	}
+
+	return RoleResponse{
+		ID:      role.ID,
+		Name:    role.Name,
+		Players: players,
+	}
+}
+
+func newPrivacySettingsResponse(settings PlayerPrivacySettings) PrivacySettingsResponse {
+	return PrivacySettingsResponse{
+		HideFromSearch:   settings.HideFromSearch,
+		HideOnlineStatus: settings.HideOnlineStatus,
+		GuestbookMode:    guestbookMode(settings),
+		HideRooms:        settings.HideRooms,
+	}
+}
+
+func newDailyCountResponses(rows []dailyCountRow) []DailyCountResponse {
+	responses := make([]DailyCountResponse, 0, len(rows))
+
+	for _, row := range rows {
+		responses = append(responses, DailyCountResponse{Day: row.Day, Count: row.Count})
+	}
+
+	return responses
+}
+
+func newCurrencyTransactionResponse(transaction CurrencyTransaction) CurrencyTransactionResponse {
+	return CurrencyTransactionResponse{
+		ID:           transaction.ID,
+		Currency:     transaction.Currency,
+		Amount:       transaction.Amount,
+		BalanceAfter: transaction.BalanceAfter,
+		Reason:       transaction.Reason,
+		CreatedAt:    transaction.CreatedAt,
+	}
+}
+
+func newVoucherRewardResponses(rewards []VoucherReward) []VoucherRewardResponse {
+	responses := make([]VoucherRewardResponse, 0, len(rewards))
+
+	for _, reward := range rewards {
+		responses = append(responses, VoucherRewardResponse{
+			Type:            reward.Type,
+			Currency:        reward.Currency,
+			Amount:          reward.Amount,
+			BadgeCode:       reward.BadgeCode,
+			FurnitureItemId: reward.FurnitureItemId,
+		})
+	}
+
+	return responses
+}
+
+func newVoucherResponse(voucher Voucher) VoucherResponse {
+	return VoucherResponse{
+		ID:             voucher.ID,
+		Code:           voucher.Code,
+		MaxUses:        voucher.MaxUses,
+		UsesCount:      voucher.UsesCount,
+		PerPlayerLimit: voucher.PerPlayerLimit,
+		ExpiresAt:      voucher.ExpiresAt,
+		CreatedAt:      voucher.CreatedAt,
+		Rewards:        newVoucherRewardResponses(voucher.Rewards),
+	}
+}
+
+func newArticleSummaryResponse(article Article) ArticleSummaryResponse {
+	return ArticleSummaryResponse{
+		ID:             article.ID,
+		Title:          article.Title,
+		Slug:           article.Slug,
+		Summary:        article.Summary,
+		HeaderImageUrl: article.HeaderImageUrl,
+		Author:         newPlayerCardResponse(article.Author),
+		PublishedAt:    article.PublishedAt,
+	}
+}
+
+func newArticleResponse(article Article) ArticleResponse {
+	return ArticleResponse{
+		ID:             article.ID,
+		Title:          article.Title,
+		Slug:           article.Slug,
+		Summary:        article.Summary,
+		BodyHtml:       article.BodyHtml,
+		HeaderImageUrl: article.HeaderImageUrl,
+		Author:         newPlayerCardResponse(article.Author),
+		PublishedAt:    article.PublishedAt,
+		UpdatedAt:      article.UpdatedAt,
+	}
+}
+
+func newAdminArticleResponse(article Article) AdminArticleResponse {
+	status := "draft"
+
+	if article.PublishedAt != nil && article.PublishedAt.After(time.Now()) {
+		status = "scheduled"
+	} else if article.PublishedAt != nil {
+		status = "published"
+	}
+
+	return AdminArticleResponse{
+		ArticleResponse: newArticleResponse(article),
+		Body:            article.Body,
+		Status:          status,
+		PreviewToken:    article.PreviewToken,
+	}
+}
+
+func newBadgeResponse(badge Badge) BadgeResponse {
+	imageUrl := badge.ImagePath
+
+	if imageUrl == "" {
+		imageUrl = fmt.Sprintf(getEnv("BADGE_IMAGE_URL_FORMAT", "/c_images/album1584/%s.gif"), badge.Code)
+	}
+
+	return BadgeResponse{
+		Code:        badge.Code,
+		Name:        badge.Name,
+		Description: badge.Description,
+		ImageUrl:    imageUrl,
+	}
+}
+
+func newPlayerBadgeResponse(playerBadge PlayerBadge) PlayerBadgeResponse {
+	return PlayerBadgeResponse{
+		BadgeResponse: newBadgeResponse(playerBadge.Badge),
+		Slot:          playerBadge.Slot,
+		AwardedAt:     playerBadge.CreatedAt,
+	}
+}
+
+func newEquippedBadgeResponses(playerBadges []PlayerBadge) []BadgeResponse {
+	var responses []BadgeResponse
+
+	for _, playerBadge := range playerBadges {
+		if playerBadge.Slot > 0 {
+			responses = append(responses, newBadgeResponse(playerBadge.Badge))
+		}
+	}
+
+	return responses
+}
+
+func newGuestbookEntryResponses(entries []GuestbookEntry) []GuestbookEntryResponse {
+	responses := make([]GuestbookEntryResponse, 0, len(entries))
+
+	for _, entry := range entries {
+		responses = append(responses, GuestbookEntryResponse{
+			ID:        entry.ID,
+			Author:    newPlayerCardResponse(entry.Author),
+			Body:      entry.Body,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+
+	return responses
+}
+
+func newRoomResponse(room Room) RoomResponse {
+	tags := []string{}
+
+	for _, tag := range strings.Split(room.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	thumbnailUrl := room.ThumbnailPath
+
+	if thumbnailUrl == "" {
+		thumbnailUrl = fmt.Sprintf(getEnv("ROOM_THUMBNAIL_URL_FORMAT", "/c_images/thumbnails/%d.png"), room.ID)
+	}
+
+	return RoomResponse{
+		ID:           room.ID,
+		Name:         room.Name,
+		Description:  room.Description,
+		Owner:        newPlayerCardResponse(room.Owner),
+		UsersNow:     room.UsersNow,
+		MaxUsers:     room.MaxUsersAllowed,
+		Tags:         tags,
+		ThumbnailUrl: thumbnailUrl,
+	}
+}
+
+func newRoomResponses(rooms []Room) []RoomResponse {
+	responses := make([]RoomResponse, 0, len(rooms))
+
+	for _, room := range rooms {
+		responses = append(responses, newRoomResponse(room))
+	}
+
+	return responses
+}
+
+func newGroupResponses(groups []Group, memberCounts map[int64]int64) []GroupResponse {
+	responses := make([]GroupResponse, 0, len(groups))
+
+	for _, group := range groups {
+		responses = append(responses, GroupResponse{
+			ID:          group.ID,
+			Name:        group.Name,
+			Description: group.Description,
+			BadgeCode:   group.BadgeCode,
+			BadgeUrl:    fmt.Sprintf(getEnv("GROUP_BADGE_URL_FORMAT", "/habbo-imaging/badge/%s.gif"), group.BadgeCode),
+			Owner:       newPlayerCardResponse(group.Owner),
+			JoinMode:    group.JoinMode,
+			MemberCount: memberCounts[group.ID],
+			CreatedAt:   group.CreatedAt,
+		})
+	}
+
+	return responses
+}
+
+func newBanInfoResponse(ban Ban) BanInfoResponse {
+	return BanInfoResponse{
+		Type:        ban.Type,
+		Reason:      ban.Reason,
+		IsPermanent: ban.IsPermanent,
+		ExpiresAt:   ban.ExpiresAt,
+	}
+}
+
+func newBanResponse(ban Ban) BanResponse {
+	return BanResponse{
+		ID:          ban.ID,
+		Type:        ban.Type,
+		Value:       ban.Value,
+		PlayerId:    ban.PlayerId,
+		Reason:      ban.Reason,
+		IssuerId:    ban.IssuerId,
+		IsPermanent: ban.IsPermanent,
+		ExpiresAt:   ban.ExpiresAt,
+		LiftedAt:    ban.LiftedAt,
+		LiftedById:  ban.LiftedById,
+		CreatedAt:   ban.CreatedAt,
+	}
+}
+
+func newAuditEventResponse(event AuditEvent) AuditEventResponse {
+	metadata := json.RawMessage("null")
+
+	if event.Metadata != "" {
+		metadata = json.RawMessage(event.Metadata)
+	}
+
+	return AuditEventResponse{
+		ID:             event.ID,
+		ActorId:        event.ActorId,
+		TargetPlayerId: event.TargetPlayerId,
+		Action:         event.Action,
+		Ip:             event.Ip,
+		UserAgent:      event.UserAgent,
+		Metadata:       metadata,
+		CreatedAt:      event.CreatedAt,
+	}
+}
+
+func newNameChangeResponses(changes []PlayerNameChange) []NameChangeResponse {
+	responses := make([]NameChangeResponse, 0, len(changes))
+
+	for _, change := range changes {
+		responses = append(responses, NameChangeResponse{
+			OldUsername: change.OldUsername,
+			NewUsername: change.NewUsername,
+			Cost:        change.Cost,
+			ChangedById: change.ChangedById,
+			CreatedAt:   change.CreatedAt,
+		})
+	}
+
+	return responses
+}
+
+func newAccountDeletionResponse(request AccountDeletionRequest) AccountDeletionResponse {
+	status := "awaiting_confirmation"
+
+	switch {
+	case request.CompletedAt != nil:
+		status = "completed"
+	case request.CancelledAt != nil:
+		status = "cancelled"
+	case request.ConfirmedAt != nil:
+		status = "scheduled"
+	}
+
+	return AccountDeletionResponse{
+		Status:       status,
+		RequestedAt:  request.CreatedAt,
+		ScheduledFor: request.ScheduledFor,
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errDailyRewardClaimed = errors.New("daily reward already claimed")
+
+func dailyRewardCalendar() [][]DailyRewardAmountResponse {
+	var calendar [][]DailyRewardAmountResponse
+
+	for _, rawDay := range strings.Split(getEnv("DAILY_REWARDS", "credits:100;credits:150;credits:200;credits:250;credits:300;pixels:100;credits:500,pixels:250"), ";") {
+		var day []DailyRewardAmountResponse
+
+		for _, rawReward := range strings.Split(rawDay, ",") {
+			parts := strings.SplitN(strings.TrimSpace(rawReward), ":", 2)
+
+			if len(parts) != 2 {
+				continue
+			}
+
+			amount, err := strconv.ParseInt(parts[1], 10, 64)
+
+			if _, exists := currencyColumns[parts[0]]; !exists || err != nil || amount < 1 {
+				continue
+			}
+
+			day = append(day, DailyRewardAmountResponse{Currency: parts[0], Amount: amount})
+		}
+
+		calendar = append(calendar, day)
+	}
+
+	return calendar
+}
+
+func advanceLoginStreak(streak PlayerLoginStreak, today time.Time) (PlayerLoginStreak, bool) {
+	lastLogin := streak.LastLoginDate.Format("2006-01-02")
+
+	if lastLogin == today.Format("2006-01-02") {
+		return streak, false
+	}
+
+	if lastLogin == today.AddDate(0, 0, -1).Format("2006-01-02") {
+		streak.CurrentStreak++
+	} else {
+		streak.CurrentStreak = 1
+	}
+
+	if streak.CurrentStreak > streak.LongestStreak {
+		streak.LongestStreak = streak.CurrentStreak
+	}
+
+	streak.LastLoginDate = today
+
+	return streak, true
+}
+
+func touchLoginStreak(tx *gorm.DB, playerId int64) (PlayerLoginStreak, error) {
+	var streak PlayerLoginStreak
+
+	today := calendarDate(time.Now().In(location))
+
+	var queryError = tx.Set("gorm:query_option", "FOR UPDATE").
+		Model(PlayerLoginStreak{}).
+		Where("player_id = ?", playerId).
+		First(&streak).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		streak = PlayerLoginStreak{
+			PlayerId:      playerId,
+			CurrentStreak: 1,
+			LongestStreak: 1,
+			LastLoginDate: today,
+		}
+
+		return streak, tx.Create(&streak).Error
+	}
+
+	if queryError != nil {
+		return streak, queryError
+	}
+
+	streak, changed := advanceLoginStreak(streak, today)
+
+	if !changed {
+		return streak, nil
+	}
+
+	return streak, tx.Save(&streak).Error
+}
+
+func recordLogin(player Player, r *http.Request) error {
+	return database.Transaction(func(tx *gorm.DB) error {
+		var updateError = tx.Model(PlayerWebsiteData{}).
+			Where("player_id = ?", player.ID).
+			Updates(map[string]interface{}{
+				"last_ip":    getUserIp(r),
+				"last_login": time.Now().In(location),
+			}).
+			Error
+
+		if updateError != nil {
+			return updateError
+		}
+
+		if err := countDailyLogin(tx, time.Now().In(location)); err != nil {
+			return err
+		}
+
+		_, streakError := touchLoginStreak(tx, player.ID)
+		return streakError
+	})
+}
+
+func newDailyRewardResponse(streak PlayerLoginStreak) DailyRewardResponse {
+	calendar := dailyRewardCalendar()
+	today := calendarDate(time.Now().In(location)).Format("2006-01-02")
+
+	response := DailyRewardResponse{
+		CurrentStreak: streak.CurrentStreak,
+		LongestStreak: streak.LongestStreak,
+		ClaimedToday:  streak.LastClaimDate != nil && streak.LastClaimDate.Format("2006-01-02") == today,
+		Calendar:      calendar,
+	}
+
+	if len(calendar) > 0 && streak.CurrentStreak > 0 {
+		response.CalendarDay = (streak.CurrentStreak-1)%len(calendar) + 1
+		response.Today = calendar[response.CalendarDay-1]
+	}
+
+	return response
+}
+
+func DailyRewardHandler(w http.ResponseWriter, r *http.Request) {
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	var streak PlayerLoginStreak
+
+	today := calendarDate(time.Now().In(location))
+
+	var queryError = database.Model(PlayerLoginStreak{}).
+		Where("player_id = ?", player.ID).
+		First(&streak).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		streak = PlayerLoginStreak{
+			PlayerId:      player.ID,
+			CurrentStreak: 1,
+			LongestStreak: 1,
+			LastLoginDate: today,
+		}
+	} else if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	} else {
+		streak, _ = advanceLoginStreak(streak, today)
+	}
+
+	encodeResponse(w, r, newDailyRewardResponse(streak))
+}
+
+func ClaimDailyRewardHandler(w http.ResponseWriter, r *http.Request) {
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	var streak PlayerLoginStreak
+
+	transactionError := database.Transaction(func(tx *gorm.DB) error {
+		var err error
+		streak, err = touchLoginStreak(tx, player.ID)
+
+		if err != nil {
+			return err
+		}
+
+		reward := newDailyRewardResponse(streak)
+
+		if reward.ClaimedToday {
+			return errDailyRewardClaimed
+		}
+
+		for _, amount := range reward.Today {
+			if _, err := adjustCurrency(tx, player.ID, amount.Currency, amount.Amount, "daily reward", nil); err != nil {
+				return err
+			}
+		}
+
+		today := calendarDate(time.Now().In(location))
+		streak.LastClaimDate = &today
+
+		return tx.Model(&streak).Update("last_claim_date", today).Error
+	})
+
+	if errors.Is(transactionError, errDailyRewardClaimed) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You've already claimed today's reward"})
+		return
+	}
+
+	if transactionError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: transactionError.Error()})
+		return
+	}
+
+	encodeResponse(w, r, newDailyRewardResponse(streak))
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"time"
+)
+
+func preloadRoomOwner(query *gorm.DB) *gorm.DB {
+	return query.
+		Preload("Owner").
+		Preload("Owner.Data").
+		Preload("Owner.AvatarData").
+		Preload("Owner.PrivacySettings")
+}
+
+func loadPlayerRooms(ownerId int64, beforeId int64, limit int) ([]Room, error) {
+	var rooms []Room
+
+	query := preloadRoomOwner(database.Model(Room{})).
+		Where("owner_id = ?", ownerId)
+
+	if beforeId > 0 {
+		query = query.Where("id < ?", beforeId)
+	}
+
+	var queryError = query.
+		Order("id DESC").
+		Limit(limit).
+		Find(&rooms).
+		Error
+
+	return rooms, queryError
+}
+
+func PopularRoomsHandler(w http.ResponseWriter, r *http.Request) {
+	limit := parseLimit(r, 20, 50)
+	ttl := time.Duration(getEnvAsInt("POPULAR_ROOMS_CACHE_SECONDS", 30)) * time.Second
+
+	rooms, err := responseCache.remember(fmt.Sprintf("popular_rooms:%d", limit), ttl, func() (interface{}, error) {
+		var rooms []Room
+
+		var queryError = preloadRoomOwner(database.Model(Room{})).
+			Where("users_now > 0").
+			Order("users_now DESC").
+			Order("id ASC").
+			Limit(limit).
+			Find(&rooms).
+			Error
+
+		return newRoomResponses(rooms), queryError
+	})
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	encodeResponse(w, r, rooms)
+}
+
+func RoomHandler(w http.ResponseWriter, r *http.Request) {
+	roomId, idError := parseIdParam(r, "id")
+
+	if idError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid room id"})
+		return
+	}
+
+	var room Room
+
+	var queryError = preloadRoomOwner(database.Model(Room{})).
+		Where("id = ?", roomId).
+		First(&room).
+		Error
+
+	if errors.Is(queryError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested room couldn't be found"})
+		return
+	}
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	encodeResponse(w, r, newRoomResponse(room))
+}
+
+func PlayerRoomsHandler(w http.ResponseWriter, r *http.Request) {
+	var player Player
+
+	var playerError = database.Model(Player{}).
+		Where("username = ?", mux.Vars(r)["username"]).
+		First(&player).
+		Error
+
+	if errors.Is(playerError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The requested profile couldn't be found"})
+		return
+	}
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	settings, settingsError := loadPrivacySettings(player.ID)
+
+	if settingsError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: settingsError.Error()})
+		return
+	}
+
+	if viewer := getOptionalPlayer(r); settings.HideRooms && (viewer == nil || viewer.ID != player.ID) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "This player's rooms are private"})
+		return
+	}
+
+	cursor, cursorError := decodeCursor(r.URL.Query().Get("cursor"))
+
+	if cursorError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid cursor"})
+		return
+	}
+
+	limit := parseLimit(r, 20, 100)
+	beforeId := int64(0)
+
+	if cursor != nil {
+		beforeId = cursor.ID
+	}
+
+	rooms, roomsError := loadPlayerRooms(player.ID, beforeId, limit+1)
+
+	if roomsError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: roomsError.Error()})
+		return
+	}
+
+	nextCursor := ""
+
+	if len(rooms) > limit {
+		rooms = rooms[:limit]
+		nextCursor = encodeCursor(pageCursor{ID: rooms[len(rooms)-1].ID})
+	}
+
+	encodePage(w, r, newRoomResponses(rooms), nextCursor)
+}
@@ -2,23 +2,55 @@ package main
 
 import (
 	"github.com/gorilla/mux"
+	"time"
 )
 
 var router *mux.Router
 
 func registerRoutes() {
 	router = mux.NewRouter().StrictSlash(true)
+	router.Use(rateLimitMiddleware(newRateLimit("global", 300, time.Minute, rateLimitByIp)))
 
-	router.HandleFunc("/auth/token", TokenRequestHandler).Methods("GET")
-	router.HandleFunc("/auth/login", PlayerLoginHandler).Methods("POST")
-	router.HandleFunc("/auth/create", PlayerCreateHandler).Methods("POST")
+	loginLimit := newRateLimit("login", 10, time.Minute, rateLimitByIp)
+	registrationLimit := newRateLimit("registration", 10, time.Hour, rateLimitByIp)
+	challengeLimit := newRateLimit("challenge", 30, time.Hour, rateLimitByIp)
+	tokenLimit := newRateLimit("token", 60, time.Minute, rateLimitByClient)
+	profileLimit := newRateLimit("profile", 60, time.Minute, rateLimitByIp)
+	ssoLimit := newRateLimit("sso", 10, time.Minute, rateLimitByPlayer)
+
+	router.Handle("/auth/token", withRateLimit(tokenLimit, TokenRequestHandler)).Methods("GET")
+	router.Handle("/auth/login", withRateLimit(loginLimit, PlayerLoginHandler)).Methods("POST")
+	router.Handle("/auth/create", withRateLimit(registrationLimit, PlayerCreateHandler)).Methods("POST")
+	router.Handle("/auth/challenge", withRateLimit(challengeLimit, RegistrationChallengeHandler)).Methods("GET")
 
 	router.HandleFunc("/reset-password/send-email", SendForgotPasswordEmailHandler).Methods("POST")
 
 	router.HandleFunc("/reset-password/{token}", GetResetPasswordLink).Methods("GET")
 	router.HandleFunc("/reset-password/{token}", UseResetPasswordLink).Methods("POST")
 
+	router.HandleFunc("/account-deletion/{token}/confirm", ConfirmAccountDeletionHandler).Methods("POST")
+
 	router.HandleFunc("/ping", PingHandler).Methods("GET")
+	router.HandleFunc("/staff", StaffHandler).Methods("GET")
+	router.HandleFunc("/hotel/stats", HotelStatsHandler).Methods("GET")
+	router.HandleFunc("/leaderboards/{metric}", LeaderboardHandler).Methods("GET")
+
+	router.HandleFunc("/badges", BadgesHandler).Methods("GET")
+
+	router.HandleFunc("/articles", ArticlesHandler).Methods("GET")
+	router.HandleFunc("/articles/preview/{token}", ArticlePreviewHandler).Methods("GET")
+	router.HandleFunc("/articles/{slug}", ArticleHandler).Methods("GET")
+	router.HandleFunc("/articles/{slug}/comments", ArticleCommentsHandler).Methods("GET")
+
+	router.Handle("/profile/{username}/guestbook", withRateLimit(profileLimit, GuestbookHandler)).Methods("GET")
+	router.Handle("/profile/{username}/rooms", withRateLimit(profileLimit, PlayerRoomsHandler)).Methods("GET")
+
+	router.HandleFunc("/rooms/popular", PopularRoomsHandler).Methods("GET")
+	router.HandleFunc("/rooms/{id}", RoomHandler).Methods("GET")
+
+	router.HandleFunc("/groups", GroupsHandler).Methods("GET")
+	router.HandleFunc("/groups/{id}", GroupHandler).Methods("GET")
+	router.HandleFunc("/groups/{id}/members", GroupMembersHandler).Methods("GET")
 
 	authRouter := router.PathPrefix("/").Subrouter()
 	authRouter.Use(authorizeMiddleware)
@@ -26,9 +58,95 @@ func registerRoutes() {
 	authRouter.HandleFunc("/auth/me", PlayerRequestHandler).Methods("GET")
 
 	authRouter.HandleFunc("/settings", UpdateSettingsHandler).Methods("POST")
+	authRouter.HandleFunc("/settings/privacy", GetPrivacySettingsHandler).Methods("GET")
+	authRouter.HandleFunc("/settings/privacy", UpdatePrivacySettingsHandler).Methods("POST")
+	authRouter.HandleFunc("/settings/security-activity", SecurityActivityHandler).Methods("GET")
+	authRouter.HandleFunc("/settings/username", ChangeUsernameHandler).Methods("POST")
+	authRouter.HandleFunc("/settings/export", DataExportHandler).Methods("GET")
+	authRouter.HandleFunc("/settings/delete-account", AccountDeletionStatusHandler).Methods("GET")
+	authRouter.HandleFunc("/settings/delete-account", RequestAccountDeletionHandler).Methods("POST")
+	authRouter.HandleFunc("/settings/delete-account", CancelAccountDeletionHandler).Methods("DELETE")
 
-	authRouter.HandleFunc("/sso-token", PlayerSsoTokenHandler).Methods("GET")
+	authRouter.Handle("/sso-token", withRateLimit(ssoLimit, PlayerSsoTokenHandler)).Methods("GET")
 	authRouter.HandleFunc("/roles", RolesHandler).Methods("GET")
+	authRouter.HandleFunc("/leaderboards/{metric}/me", LeaderboardRankHandler).Methods("GET")
+	authRouter.HandleFunc("/currency/transactions", CurrencyTransactionsHandler).Methods("GET")
+	authRouter.HandleFunc("/vouchers/redeem", RedeemVoucherHandler).Methods("POST")
+	authRouter.HandleFunc("/daily-reward", DailyRewardHandler).Methods("GET")
+	authRouter.HandleFunc("/daily-reward/claim", ClaimDailyRewardHandler).Methods("POST")
+	authRouter.HandleFunc("/badges/mine", PlayerBadgesHandler).Methods("GET")
+	authRouter.HandleFunc("/badges/equipped", EquipBadgesHandler).Methods("POST")
+
+	authRouter.HandleFunc("/friends", FriendsHandler).Methods("GET")
+	authRouter.HandleFunc("/friends/requests", FriendRequestsHandler).Methods("GET")
+	authRouter.HandleFunc("/friends/requests", SendFriendRequestHandler).Methods("POST")
+	authRouter.HandleFunc("/friends/requests/{id}/accept", AcceptFriendRequestHandler).Methods("POST")
+	authRouter.HandleFunc("/friends/requests/{id}/decline", DeclineFriendRequestHandler).Methods("POST")
+	authRouter.HandleFunc("/friends/{id}", RemoveFriendHandler).Methods("DELETE")
+	authRouter.HandleFunc("/blocks/{id}", BlockPlayerHandler).Methods("POST")
+	authRouter.HandleFunc("/blocks/{id}", UnblockPlayerHandler).Methods("DELETE")
+
+	authRouter.HandleFunc("/profile/{username}/guestbook", CreateGuestbookEntryHandler).Methods("POST")
+	authRouter.HandleFunc("/guestbook/{id}", DeleteGuestbookEntryHandler).Methods("DELETE")
+
+	authRouter.HandleFunc("/groups", CreateGroupHandler).Methods("POST")
+	authRouter.HandleFunc("/groups/{id}", UpdateGroupHandler).Methods("POST")
+	authRouter.HandleFunc("/groups/{id}/join", JoinGroupHandler).Methods("POST")
+	authRouter.HandleFunc("/groups/{id}/leave", LeaveGroupHandler).Methods("POST")
+	authRouter.HandleFunc("/groups/{id}/requests", GroupJoinRequestsHandler).Methods("GET")
+	authRouter.HandleFunc("/groups/{id}/requests/{player_id}/accept", AcceptGroupJoinRequestHandler).Methods("POST")
+	authRouter.HandleFunc("/groups/{id}/requests/{player_id}/decline", DeclineGroupJoinRequestHandler).Methods("POST")
+	authRouter.HandleFunc("/groups/{id}/members/{player_id}", UpdateGroupMemberRankHandler).Methods("POST")
+	authRouter.HandleFunc("/groups/{id}/members/{player_id}", RemoveGroupMemberHandler).Methods("DELETE")
+
+	authRouter.HandleFunc("/articles/{slug}/comments", CreateArticleCommentHandler).Methods("POST")
+	authRouter.HandleFunc("/articles/{slug}/reactions", ReactToArticleHandler).Methods("POST")
+	authRouter.HandleFunc("/articles/{slug}/reactions", RemoveArticleReactionHandler).Methods("DELETE")
+	authRouter.HandleFunc("/comments/{id}", UpdateCommentHandler).Methods("POST")
+	authRouter.HandleFunc("/comments/{id}", DeleteCommentHandler).Methods("DELETE")
+	authRouter.HandleFunc("/comments/{id}/reactions", ReactToCommentHandler).Methods("POST")
+	authRouter.HandleFunc("/comments/{id}/reactions", RemoveCommentReactionHandler).Methods("DELETE")
+
+	statisticsRouter := authRouter.PathPrefix("/hotel/stats").Subrouter()
+	statisticsRouter.Use(requirePermission(permissionViewStatistics))
+
+	statisticsRouter.HandleFunc("/history", HotelStatsHistoryHandler).Methods("GET")
+
+	adminRouter := authRouter.PathPrefix("/admin").Subrouter()
+
+	adminRouter.Handle("/players", withPermission(permissionHousekeeping, HousekeepingSearchHandler)).Methods("GET")
+	adminRouter.Handle("/players/{id}", withPermission(permissionHousekeeping, HousekeepingPlayerHandler)).Methods("GET")
+	adminRouter.Handle("/players/{id}", withPermission(permissionHousekeeping, HousekeepingUpdatePlayerHandler)).Methods("POST")
+	adminRouter.Handle("/players/{id}/password-reset", withPermission(permissionHousekeeping, HousekeepingPasswordResetHandler)).Methods("POST")
+	adminRouter.Handle("/players/{id}/revoke-sessions", withPermission(permissionHousekeeping, HousekeepingRevokeSessionsHandler)).Methods("POST")
+	adminRouter.Handle("/players/{id}/linked", withPermission(permissionHousekeeping, HousekeepingLinkedAccountsHandler)).Methods("GET")
+	adminRouter.Handle("/players/{id}/names", withPermission(permissionHousekeeping, NameHistoryHandler)).Methods("GET")
+
+	adminRouter.Handle("/players/{id}/currency", withPermission(permissionManageCurrency, AdjustCurrencyHandler)).Methods("POST")
+
+	adminRouter.Handle("/vouchers", withPermission(permissionManageVouchers, VouchersHandler)).Methods("GET")
+	adminRouter.Handle("/vouchers", withPermission(permissionManageVouchers, CreateVoucherHandler)).Methods("POST")
+	adminRouter.Handle("/vouchers/{id}/redemptions", withPermission(permissionManageVouchers, VoucherRedemptionsHandler)).Methods("GET")
+
+	adminRouter.Handle("/articles", withPermission(permissionWriteArticles, AdminArticlesHandler)).Methods("GET")
+	adminRouter.Handle("/articles", withPermission(permissionWriteArticles, CreateArticleHandler)).Methods("POST")
+	adminRouter.Handle("/articles/{id}", withPermission(permissionWriteArticles, UpdateArticleHandler)).Methods("POST")
+	adminRouter.Handle("/articles/{id}/publish", withPermission(permissionWriteArticles, PublishArticleHandler)).Methods("POST")
+	adminRouter.Handle("/articles/{id}/unpublish", withPermission(permissionWriteArticles, UnpublishArticleHandler)).Methods("POST")
+
+	adminRouter.Handle("/comments/{id}/hide", withPermission(permissionModerateComments, HideCommentHandler)).Methods("POST")
+	adminRouter.Handle("/comments/{id}/unhide", withPermission(permissionModerateComments, UnhideCommentHandler)).Methods("POST")
+
+	adminRouter.Handle("/badges", withPermission(permissionManageBadges, SaveBadgeHandler)).Methods("POST")
+	adminRouter.Handle("/players/{id}/badges", withPermission(permissionManageBadges, AwardBadgeHandler)).Methods("POST")
+	adminRouter.Handle("/players/{id}/badges/{code}", withPermission(permissionManageBadges, RevokeBadgeHandler)).Methods("DELETE")
+
+	adminRouter.Handle("/audit", withPermission(permissionViewAudit, AuditEventsHandler)).Methods("GET")
+
+	adminRouter.Handle("/bans", withPermission(permissionManageBans, BansHandler)).Methods("GET")
+	adminRouter.Handle("/bans", withPermission(permissionManageBans, IssueBanHandler)).Methods("POST")
+	adminRouter.Handle("/bans/{id}/lift", withPermission(permissionManageBans, LiftBanHandler)).Methods("POST")
 
-	router.HandleFunc("/profile/{username}", GetPlayerProfileHandler).Methods("GET")
+	router.Handle("/profile/{username}", withRateLimit(profileLimit, GetPlayerProfileHandler)).Methods("GET")
+	router.Handle("/players", withRateLimit(profileLimit, SearchPlayersHandler)).Methods("GET")
 }
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type playerSearchSort struct {
+	column     string
+	descending bool
+	isTime     bool
+}
+
+type playerSearchRow struct {
+	ID               int64
+	Username         string
+	CreatedAt        time.Time
+	FigureCode       string
+	Motto            string
+	IsOnline         int16
+	LastOnline       *time.Time
+	HideOnlineStatus *bool
+}
+
+var playerSearchSorts = map[string]playerSearchSort{
+	"username":    {column: "players.username"},
+	"newest":      {column: "players.created_at", descending: true, isTime: true},
+	"last_online": {column: "player_data.last_online", descending: true, isTime: true},
+}
+
+func SearchPlayersHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	sortName := query.Get("sort")
+
+	if sortName == "" {
+		sortName = "username"
+	}
+
+	sort, sortExists := playerSearchSorts[sortName]
+
+	if !sortExists {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Unknown sort option"})
+		return
+	}
+
+	cursor, cursorError := decodeCursor(query.Get("cursor"))
+
+	if cursorError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid cursor"})
+		return
+	}
+
+	limit := parseLimit(r, 20, 100)
+
+	search := playerCardQuery().
+		Where("player_privacy_settings.hide_from_search IS NULL OR player_privacy_settings.hide_from_search = ?", false)
+
+	if prefix := strings.TrimSpace(query.Get("q")); prefix != "" {
+		search = search.Where("players.username LIKE ?", escapeLike(prefix)+"%")
+	}
+
+	if query.Get("online") == "true" {
+		search = search.
+			Where("player_data.is_online = ?", 1).
+			Where("player_privacy_settings.hide_online_status IS NULL OR player_privacy_settings.hide_online_status = ?", false)
+	}
+
+	if rawRole := query.Get("role"); rawRole != "" {
+		roleId, err := strconv.ParseInt(rawRole, 10, 64)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid role"})
+			return
+		}
+
+		search = search.
+			Joins("INNER JOIN player_role ON player_role.player_id = players.id").
+			Where("player_role.role_id = ?", roleId)
+	}
+
+	if rawRegisteredAfter := query.Get("registered_after"); rawRegisteredAfter != "" {
+		registeredAfter, err := parseDateParam(rawRegisteredAfter)
+
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid registered_after date"})
+			return
+		}
+
+		search = search.Where("players.created_at > ?", registeredAfter)
+	}
+
+	direction, comparison := "ASC", ">"
+
+	if sort.descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	if cursor != nil {
+		var cursorValue interface{} = cursor.Value
+
+		if sort.isTime {
+			parsed, err := time.Parse(time.RFC3339Nano, cursor.Value)
+
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid cursor"})
+				return
+			}
+
+			cursorValue = parsed
+		}
+
+		search = search.Where(
+			fmt.Sprintf("%s %s ? OR (%s = ? AND players.id %s ?)", sort.column, comparison, sort.column, comparison),
+			cursorValue, cursorValue, cursor.ID)
+	}
+
+	var rows []playerSearchRow
+
+	var queryError = search.
+		Order(fmt.Sprintf("%s %s", sort.column, direction)).
+		Order(fmt.Sprintf("players.id %s", direction)).
+		Limit(limit + 1).
+		Scan(&rows).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	nextCursor := ""
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		nextCursor = encodeCursor(pageCursor{Value: last.sortValue(sortName), ID: last.ID})
+	}
+
+	cards := make([]PlayerCardResponse, 0, len(rows))
+
+	for _, row := range rows {
+		cards = append(cards, row.card())
+	}
+
+	encodePage(w, r, cards, nextCursor)
+}
+
+func playerCardQuery() *gorm.DB {
+	return database.Table("players").
+		Select("players.id, players.username, players.created_at, " +
+			"player_avatar_data.figure_code, player_avatar_data.motto, " +
+			"player_data.is_online, player_data.last_online, " +
+			"player_privacy_settings.hide_online_status").
+		Joins("LEFT JOIN player_data ON player_data.player_id = players.id").
+		Joins("LEFT JOIN player_avatar_data ON player_avatar_data.player_id = players.id").
+		Joins("LEFT JOIN player_privacy_settings ON player_privacy_settings.player_id = players.id")
+}
+
+func loadPlayerCards(playerIds []int64) ([]PlayerCardResponse, error) {
+	cards := make([]PlayerCardResponse, 0, len(playerIds))
+
+	if len(playerIds) == 0 {
+		return cards, nil
+	}
+
+	var rows []playerSearchRow
+
+	var queryError = playerCardQuery().
+		Where("players.id IN (?)", playerIds).
+		Order("players.username ASC").
+		Scan(&rows).
+		Error
+
+	for _, row := range rows {
+		cards = append(cards, row.card())
+	}
+
+	return cards, queryError
+}
+
+func (row playerSearchRow) card() PlayerCardResponse {
+	return PlayerCardResponse{
+		ID:         row.ID,
+		Username:   row.Username,
+		FigureCode: row.FigureCode,
+		Motto:      row.Motto,
+		IsOnline:   row.IsOnline == 1 && (row.HideOnlineStatus == nil || !*row.HideOnlineStatus),
+	}
+}
+
+func (row playerSearchRow) sortValue(sortName string) string {
+	switch sortName {
+	case "newest":
+		return row.CreatedAt.Format(time.RFC3339Nano)
+	case "last_online":
+		if row.LastOnline == nil {
+			return time.Time{}.Format(time.RFC3339Nano)
+		}
+
+		return row.LastOnline.Format(time.RFC3339Nano)
+	}
+
+	return row.Username
+}
+
+func parseDateParam(raw string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, raw); err == nil {
+		return parsed, nil
+	}
+
+	return time.ParseInLocation("2006-01-02", raw, location)
+}
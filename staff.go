@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"time"
+)
+
+const staffCacheKey = "staff"
+
+func StaffHandler(w http.ResponseWriter, r *http.Request) {
+	ttl := time.Duration(getEnvAsInt("STAFF_CACHE_SECONDS", 60)) * time.Second
+
+	staff, err := responseCache.remember(staffCacheKey, ttl, func() (interface{}, error) {
+		return loadStaffRoles()
+	})
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	encodeResponse(w, r, staff)
+}
+
+func loadStaffRoles() ([]StaffRoleResponse, error) {
+	var roles []Role
+
+	query := database.Model(&Role{}).
+		Preload("Players", func(db *gorm.DB) *gorm.DB {
+			return db.Order("players.username ASC")
+		}).
+		Preload("Players.Data").
+		Preload("Players.AvatarData").
+		Preload("Players.PrivacySettings")
+
+	var queryError = preloadEquippedBadges(query, "Players.Badges").
+		Where("is_hidden = ?", false).
+		Order("sort_order ASC").
+		Order("id ASC").
+		Find(&roles).
+		Error
+
+	if queryError != nil {
+		return nil, queryError
+	}
+
+	return buildStaffTree(roles), nil
+}
+
+func buildStaffTree(roles []Role) []StaffRoleResponse {
+	visible := map[int64]bool{}
+	children := map[int64][]Role{}
+	var topLevel []Role
+
+	for _, role := range roles {
+		visible[role.ID] = true
+	}
+
+	for _, role := range roles {
+		if role.ParentId != nil && visible[*role.ParentId] && *role.ParentId != role.ID {
+			children[*role.ParentId] = append(children[*role.ParentId], role)
+		} else {
+			topLevel = append(topLevel, role)
+		}
+	}
+
+	var build func(roles []Role, seen map[int64]bool) []StaffRoleResponse
+
+	build = func(roles []Role, seen map[int64]bool) []StaffRoleResponse {
+		responses := make([]StaffRoleResponse, 0, len(roles))
+
+		for _, role := range roles {
+			if seen[role.ID] {
+				continue
+			}
+
+			seen[role.ID] = true
+
+			players := make([]PlayerCardResponse, 0, len(role.Players))
+
+			for _, player := range role.Players {
+				players = append(players, newPlayerCardResponse(player))
+			}
+
+			responses = append(responses, StaffRoleResponse{
+				ID:          role.ID,
+				Name:        role.Name,
+				BadgeCode:   role.BadgeCode,
+				Colour:      role.Colour,
+				Description: role.Description,
+				Players:     players,
+				Children:    build(children[role.ID], seen),
+			})
+		}
+
+		return responses
+	}
+
+	return build(topLevel, map[int64]bool{})
+}
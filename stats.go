@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jinzhu/gorm"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const hotelStatsCacheKey = "hotel_stats"
+
+type dailyCountRow struct {
+	Day   string
+	Count int64
+}
+
+func HotelStatsHandler(w http.ResponseWriter, r *http.Request) {
+	ttl := time.Duration(getEnvAsInt("HOTEL_STATS_CACHE_SECONDS", 30)) * time.Second
+
+	stats, err := responseCache.remember(hotelStatsCacheKey, ttl, func() (interface{}, error) {
+		return loadHotelStats()
+	})
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	encodeResponse(w, r, stats)
+}
+
+func HotelStatsHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	days, err := strconv.Atoi(r.URL.Query().Get("days"))
+
+	if err != nil || days < 1 || days > 365 {
+		days = 30
+	}
+
+	ttl := time.Duration(getEnvAsInt("HOTEL_STATS_CACHE_SECONDS", 30)) * time.Second
+	cacheKey := fmt.Sprintf("hotel_stats_history:%d", days)
+
+	history, err := responseCache.remember(cacheKey, ttl, func() (interface{}, error) {
+		return loadHotelStatsHistory(days)
+	})
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	encodeResponse(w, r, history)
+}
+
+func loadHotelStats() (HotelStatsResponse, error) {
+	var stats HotelStatsResponse
+
+	usersOnline, onlineError := recordOnlinePeak()
+
+	if onlineError != nil {
+		return stats, onlineError
+	}
+
+	stats.UsersOnline = usersOnline
+
+	if err := database.Model(Player{}).Count(&stats.RegisteredPlayers).Error; err != nil {
+		return stats, err
+	}
+
+	var today HotelOnlinePeak
+
+	todayError := database.Model(HotelOnlinePeak{}).
+		Where("date = ?", startOfDay(time.Now().In(location)).Format("2006-01-02")).
+		First(&today).
+		Error
+
+	if todayError != nil && !errors.Is(todayError, gorm.ErrRecordNotFound) {
+		return stats, todayError
+	}
+
+	stats.PeakOnlineToday = today.PeakOnline
+
+	var allTime HotelOnlinePeak
+
+	allTimeError := database.Model(HotelOnlinePeak{}).
+		Order("peak_online DESC").
+		First(&allTime).
+		Error
+
+	if allTimeError != nil && !errors.Is(allTimeError, gorm.ErrRecordNotFound) {
+		return stats, allTimeError
+	}
+
+	stats.PeakOnlineAllTime = allTime.PeakOnline
+
+	var newest []Player
+
+	var newestError = database.Model(Player{}).
+		Preload("Data").
+		Preload("AvatarData").
+		Preload("PrivacySettings").
+		Order("created_at DESC").
+		Limit(getEnvAsInt("HOTEL_STATS_NEWEST_MEMBERS", 5)).
+		Find(&newest).
+		Error
+
+	if newestError != nil {
+		return stats, newestError
+	}
+
+	stats.NewestMembers = make([]PlayerCardResponse, 0, len(newest))
+
+	for _, player := range newest {
+		stats.NewestMembers = append(stats.NewestMembers, newPlayerCardResponse(player))
+	}
+
+	return stats, nil
+}
+
+func loadHotelStatsHistory(days int) (HotelStatsHistoryResponse, error) {
+	var history HotelStatsHistoryResponse
+
+	since := startOfDay(time.Now().In(location)).AddDate(0, 0, -(days - 1))
+
+	var registeredAt []time.Time
+
+	var registrationsError = database.Model(Player{}).
+		Where("created_at >= ?", since).
+		Pluck("created_at", &registeredAt).
+		Error
+
+	if registrationsError != nil {
+		return history, registrationsError
+	}
+
+	var logins []dailyCountRow
+
+	var loginsError = database.Table("hotel_daily_logins").
+		Select("DATE_FORMAT(`date`, '%Y-%m-%d') AS day, logins AS count").
+		Where("`date` >= ?", calendarDate(since)).
+		Order("`date` ASC").
+		Scan(&logins).
+		Error
+
+	if loginsError != nil {
+		return history, loginsError
+	}
+
+	history.Registrations = newDailyCountResponses(countByHotelDay(registeredAt))
+	history.Logins = newDailyCountResponses(logins)
+
+	return history, nil
+}
+
+func countByHotelDay(times []time.Time) []dailyCountRow {
+	var rows []dailyCountRow
+
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	for _, t := range times {
+		day := t.In(location).Format("2006-01-02")
+
+		if len(rows) > 0 && rows[len(rows)-1].Day == day {
+			rows[len(rows)-1].Count++
+			continue
+		}
+
+		rows = append(rows, dailyCountRow{Day: day, Count: 1})
+	}
+
+	return rows
+}
+
+func countDailyLogin(tx *gorm.DB, now time.Time) error {
+	return tx.Exec("INSERT INTO hotel_daily_logins (`date`, logins) VALUES (?, 1) ON DUPLICATE KEY UPDATE logins = logins + 1",
+		calendarDate(now)).
+		Error
+}
+
+func backfillDailyLogins() error {
+	var loggedInAt []time.Time
+
+	if err := database.Model(AuditEvent{}).Where("action = ?", auditLogin).Pluck("created_at", &loggedInAt).Error; err != nil {
+		return err
+	}
+
+	for _, row := range countByHotelDay(loggedInAt) {
+		var insertError = database.Exec("INSERT INTO hotel_daily_logins (`date`, logins) VALUES (?, ?) ON DUPLICATE KEY UPDATE logins = logins + VALUES(logins)",
+			row.Day, row.Count).
+			Error
+
+		if insertError != nil {
+			return insertError
+		}
+	}
+
+	return nil
+}
+
+func recordOnlinePeak() (int64, error) {
+	var usersOnline int64
+
+	if err := database.Model(PlayerData{}).Where("is_online = ?", 1).Count(&usersOnline).Error; err != nil {
+		return 0, err
+	}
+
+	now := time.Now().In(location)
+
+	var upsertError = database.Exec(
+		"INSERT INTO hotel_online_peaks (`date`, peak_online, recorded_at) VALUES (?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE "+
+			"recorded_at = IF(VALUES(peak_online) > peak_online, VALUES(recorded_at), recorded_at), "+
+			"peak_online = GREATEST(peak_online, VALUES(peak_online))",
+		calendarDate(now), usersOnline, now).
+		Error
+
+	return usersOnline, upsertError
+}
+
+func startOnlinePeakTracker() {
+	interval := time.Duration(getEnvAsInt("ONLINE_PEAK_SAMPLE_SECONDS", 60)) * time.Second
+
+	go func() {
+		for range time.Tick(interval) {
+			if _, err := recordOnlinePeak(); err != nil {
+				log.Println("Failed to record online peak:", err)
+			}
+		}
+	}()
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -18,14 +18,16 @@ type Credentials struct {
 }
 
 type Player struct {
-	ID         int64            `json:"id" gorm:"primary_key"`
-	Username   string           `json:"username"`
-	Email      string           `json:"email"`
-	Password   string           `json:"-"`
-	CreatedAt  time.Time        `json:"created_at"`
-	Data       PlayerData       `json:"data"`
-	Roles      []Role           `json:"roles" gorm:"many2many:player_role;"`
-	AvatarData PlayerAvatarData `json:"avatar_data"`
+	ID              int64                  `json:"id" gorm:"primary_key"`
+	Username        string                 `json:"username"`
+	Email           string                 `json:"email"`
+	Password        string                 `json:"-"`
+	CreatedAt       time.Time              `json:"created_at"`
+	Data            PlayerData             `json:"data"`
+	Roles           []Role                 `json:"roles" gorm:"many2many:player_role;"`
+	AvatarData      PlayerAvatarData       `json:"avatar_data"`
+	Badges          []PlayerBadge          `json:"badges" gorm:"foreignkey:PlayerId"`
+	PrivacySettings *PlayerPrivacySettings `json:"-" gorm:"foreignkey:PlayerId"`
 }
 
 type PlayerData struct {
@@ -84,9 +86,16 @@ type PlayerPasswordResetLink struct {
 }
 
 type Role struct {
-	ID      int64    `json:"id" gorm:"primary_key"`
-	Name    string   `json:"name"`
-	Players []Player `json:"players" gorm:"many2many:player_role;"`
+	ID          int64    `json:"id" gorm:"primary_key"`
+	Name        string   `json:"name"`
+	BadgeCode   string   `json:"badge_code"`
+	Colour      string   `json:"colour"`
+	Description string   `json:"description"`
+	SortOrder   int      `json:"sort_order" gorm:"default:0"`
+	IsHidden    bool     `json:"is_hidden" gorm:"default:false"`
+	ParentId    *int64   `json:"parent_id"`
+	FriendLimit int      `json:"friend_limit" gorm:"default:0"`
+	Players     []Player `json:"players" gorm:"many2many:player_role;"`
 }
 
 type PlayerCreateRequest struct {
@@ -94,4 +103,397 @@ type PlayerCreateRequest struct {
 	Email           string `json:"email" validate:"required,email"`
 	Password        string `json:"password" validate:"required,min=10"`
 	PasswordConfirm string `json:"password_confirm" validate:"required,eqfield=Password"`
+	Challenge       string `json:"challenge"`
+	Solution        string `json:"solution"`
+	CaptchaToken    string `json:"captcha_token"`
+	Website         string `json:"website"`
+	PhoneNumber     string `json:"phone_number"`
+}
+
+type PlayerPrivacySettings struct {
+	ID               int64  `json:"id" gorm:"primary_key"`
+	PlayerId         int64  `json:"player_id" gorm:"unique_index"`
+	HideFromSearch   bool   `json:"hide_from_search" gorm:"default:false"`
+	HideOnlineStatus bool   `json:"hide_online_status" gorm:"default:false"`
+	GuestbookMode    string `json:"guestbook_mode" gorm:"size:10;default:'open'"`
+	HideRooms        bool   `json:"hide_rooms" gorm:"default:false"`
+}
+
+type RolePermission struct {
+	ID     int64  `json:"id" gorm:"primary_key"`
+	RoleId int64  `json:"role_id" gorm:"index"`
+	Name   string `json:"name"`
+}
+
+type HotelOnlinePeak struct {
+	ID         int64     `json:"id" gorm:"primary_key"`
+	Date       time.Time `json:"date" gorm:"type:DATE;unique_index"`
+	PeakOnline int64     `json:"peak_online"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+type HotelDailyLogins struct {
+	ID     int64     `json:"id" gorm:"primary_key"`
+	Date   time.Time `json:"date" gorm:"type:DATE;unique_index"`
+	Logins int64     `json:"logins"`
+}
+
+type LeaderboardEntry struct {
+	ID          int64     `json:"id" gorm:"primary_key"`
+	Metric      string    `json:"metric" gorm:"index:idx_leaderboard_metric_position"`
+	Position    int64     `json:"position" gorm:"index:idx_leaderboard_metric_position"`
+	PlayerId    int64     `json:"player_id" gorm:"index"`
+	Value       int64     `json:"value"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+type CurrencyTransaction struct {
+	ID           int64     `json:"id" gorm:"primary_key"`
+	PlayerId     int64     `json:"player_id" gorm:"index"`
+	Currency     string    `json:"currency" gorm:"index"`
+	Amount       int64     `json:"amount"`
+	BalanceAfter int64     `json:"balance_after"`
+	Reason       string    `json:"reason"`
+	ActorId      *int64    `json:"actor_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type CurrencyAdjustRequest struct {
+	Currency string `json:"currency" validate:"required,oneof=credits pixels seasonal gotw"`
+	Amount   int64  `json:"amount" validate:"required"`
+	Reason   string `json:"reason" validate:"required,max=255"`
+}
+
+type PlayerFurnitureItem struct {
+	ID              int64     `json:"id" gorm:"primary_key"`
+	PlayerId        int64     `json:"player_id"`
+	FurnitureItemId int64     `json:"furniture_item_id"`
+	MetaData        string    `json:"meta_data"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type Voucher struct {
+	ID             int64           `json:"id" gorm:"primary_key"`
+	Code           string          `json:"code" gorm:"unique_index"`
+	MaxUses        int             `json:"max_uses"`
+	UsesCount      int             `json:"uses_count"`
+	PerPlayerLimit int             `json:"per_player_limit"`
+	ExpiresAt      *time.Time      `json:"expires_at" gorm:"type:TIMESTAMP;null;default:null"`
+	CreatedById    int64           `json:"created_by_id"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Rewards        []VoucherReward `json:"rewards"`
+}
+
+type VoucherReward struct {
+	ID              int64  `json:"id" gorm:"primary_key"`
+	VoucherId       int64  `json:"voucher_id" gorm:"index"`
+	Type            string `json:"type"`
+	Currency        string `json:"currency"`
+	Amount          int64  `json:"amount"`
+	BadgeCode       string `json:"badge_code"`
+	FurnitureItemId int64  `json:"furniture_item_id"`
+}
+
+type VoucherRedemption struct {
+	ID         int64     `json:"id" gorm:"primary_key"`
+	VoucherId  int64     `json:"voucher_id" gorm:"index"`
+	PlayerId   int64     `json:"player_id" gorm:"index"`
+	Ip         string    `json:"ip"`
+	RedeemedAt time.Time `json:"redeemed_at"`
+}
+
+type VoucherCreateRequest struct {
+	Code           string                 `json:"code" validate:"omitempty,alphanum,min=4,max=32"`
+	MaxUses        int                    `json:"max_uses" validate:"min=0"`
+	PerPlayerLimit int                    `json:"per_player_limit" validate:"min=0"`
+	ExpiresAt      *time.Time             `json:"expires_at"`
+	Rewards        []VoucherRewardRequest `json:"rewards" validate:"required,min=1,dive"`
+}
+
+type VoucherRewardRequest struct {
+	Type            string `json:"type" validate:"required"`
+	Currency        string `json:"currency"`
+	Amount          int64  `json:"amount" validate:"min=0,max=1000000"`
+	BadgeCode       string `json:"badge_code"`
+	FurnitureItemId int64  `json:"furniture_item_id"`
+}
+
+type VoucherRedeemRequest struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+type PlayerLoginStreak struct {
+	ID            int64      `json:"id" gorm:"primary_key"`
+	PlayerId      int64      `json:"player_id" gorm:"unique_index"`
+	CurrentStreak int        `json:"current_streak"`
+	LongestStreak int        `json:"longest_streak"`
+	LastLoginDate time.Time  `json:"last_login_date" gorm:"type:DATE"`
+	LastClaimDate *time.Time `json:"last_claim_date" gorm:"type:DATE;null;default:null"`
+}
+
+type Article struct {
+	ID             int64      `json:"id" gorm:"primary_key"`
+	Title          string     `json:"title"`
+	Slug           string     `json:"slug" gorm:"unique_index"`
+	Summary        string     `json:"summary"`
+	Body           string     `json:"body" gorm:"type:TEXT"`
+	BodyHtml       string     `json:"body_html" gorm:"type:TEXT"`
+	HeaderImageUrl string     `json:"header_image_url"`
+	AuthorId       int64      `json:"author_id" gorm:"index"`
+	Author         Player     `json:"author" gorm:"foreignkey:AuthorId"`
+	PreviewToken   string     `json:"preview_token" gorm:"index"`
+	PublishedAt    *time.Time `json:"published_at" gorm:"type:TIMESTAMP;null;default:null;index"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type ArticleRequest struct {
+	Title          string `json:"title" validate:"required,max=120"`
+	Slug           string `json:"slug" validate:"omitempty,max=120"`
+	Summary        string `json:"summary" validate:"max=500"`
+	Body           string `json:"body" validate:"required"`
+	HeaderImageUrl string `json:"header_image_url" validate:"omitempty,http_url,max=255"`
+}
+
+type ArticlePublishRequest struct {
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+type ArticleComment struct {
+	ID        int64      `json:"id" gorm:"primary_key"`
+	ArticleId int64      `json:"article_id" gorm:"index"`
+	PlayerId  int64      `json:"player_id" gorm:"index"`
+	Player    Player     `json:"player" gorm:"foreignkey:PlayerId"`
+	ParentId  *int64     `json:"parent_id" gorm:"index"`
+	Body      string     `json:"body" gorm:"type:TEXT"`
+	IsHidden  bool       `json:"is_hidden" gorm:"default:false"`
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at" gorm:"type:TIMESTAMP;null;default:null"`
+	DeletedAt *time.Time `json:"deleted_at" gorm:"type:TIMESTAMP;null;default:null"`
+}
+
+type ArticleReaction struct {
+	ID        int64     `json:"id" gorm:"primary_key"`
+	ArticleId int64     `json:"article_id" gorm:"unique_index:idx_article_reaction"`
+	CommentId int64     `json:"comment_id" gorm:"unique_index:idx_article_reaction"`
+	PlayerId  int64     `json:"player_id" gorm:"unique_index:idx_article_reaction"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GuestbookEntry struct {
+	ID              int64      `json:"id" gorm:"primary_key"`
+	ProfilePlayerId int64      `json:"profile_player_id" gorm:"index"`
+	AuthorId        int64      `json:"author_id" gorm:"index"`
+	Author          Player     `json:"author" gorm:"foreignkey:AuthorId"`
+	Body            string     `json:"body" gorm:"type:TEXT"`
+	CreatedAt       time.Time  `json:"created_at"`
+	DeletedAt       *time.Time `json:"deleted_at" gorm:"type:TIMESTAMP;null;default:null"`
+}
+
+type GuestbookEntryRequest struct {
+	Body string `json:"body" validate:"required,max=500"`
+}
+
+type CommentRequest struct {
+	Body     string `json:"body" validate:"required,max=1000"`
+	ParentId *int64 `json:"parent_id"`
+}
+
+type ReactionRequest struct {
+	Type string `json:"type" validate:"required,max=20"`
+}
+
+type Badge struct {
+	ID          int64  `json:"id" gorm:"primary_key"`
+	Code        string `json:"code" gorm:"unique_index"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ImagePath   string `json:"image_path"`
+}
+
+type PlayerBadge struct {
+	ID        int64     `json:"id" gorm:"primary_key"`
+	PlayerId  int64     `json:"player_id" gorm:"unique_index:idx_player_badge"`
+	BadgeId   int64     `json:"badge_id" gorm:"unique_index:idx_player_badge"`
+	Badge     Badge     `json:"badge" gorm:"foreignkey:BadgeId"`
+	Slot      int       `json:"slot"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BadgeRequest struct {
+	Code        string `json:"code" validate:"required,max=32"`
+	Name        string `json:"name" validate:"required,max=64"`
+	Description string `json:"description" validate:"max=255"`
+	ImagePath   string `json:"image_path" validate:"max=255"`
+}
+
+type BadgeAwardRequest struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+type EquipBadgesRequest struct {
+	Codes []string `json:"codes" validate:"dive,required,max=32"`
+}
+
+type PlayerFriendship struct {
+	ID             int64     `json:"id" gorm:"primary_key"`
+	OriginPlayerId int64     `json:"origin_player_id"`
+	TargetPlayerId int64     `json:"target_player_id"`
+	Status         int       `json:"status"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type Room struct {
+	ID              int64  `json:"id" gorm:"primary_key"`
+	OwnerId         int64  `json:"owner_id"`
+	Owner           Player `json:"owner" gorm:"foreignkey:OwnerId"`
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	UsersNow        int    `json:"users_now"`
+	MaxUsersAllowed int    `json:"max_users_allowed"`
+	Tags            string `json:"tags"`
+	ThumbnailPath   string `json:"thumbnail_path"`
+}
+
+type Group struct {
+	ID          int64     `json:"id" gorm:"primary_key"`
+	Name        string    `json:"name" gorm:"unique_index"`
+	Description string    `json:"description" gorm:"type:TEXT"`
+	BadgeCode   string    `json:"badge_code"`
+	OwnerId     int64     `json:"owner_id" gorm:"index"`
+	Owner       Player    `json:"owner" gorm:"foreignkey:OwnerId"`
+	JoinMode    string    `json:"join_mode" gorm:"size:10;default:'open'"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type GroupMember struct {
+	ID        int64     `json:"id" gorm:"primary_key"`
+	GroupId   int64     `json:"group_id" gorm:"unique_index:idx_group_member"`
+	PlayerId  int64     `json:"player_id" gorm:"unique_index:idx_group_member;index"`
+	Rank      string    `json:"rank" gorm:"size:10"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GroupJoinRequest struct {
+	ID        int64     `json:"id" gorm:"primary_key"`
+	GroupId   int64     `json:"group_id" gorm:"unique_index:idx_group_join_request"`
+	PlayerId  int64     `json:"player_id" gorm:"unique_index:idx_group_join_request"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GroupRequest struct {
+	Name        string `json:"name" validate:"required,max=50"`
+	Description string `json:"description" validate:"max=1000"`
+	BadgeCode   string `json:"badge_code" validate:"max=100"`
+	JoinMode    string `json:"join_mode" validate:"omitempty,oneof=open request closed"`
+}
+
+type GroupRankRequest struct {
+	Rank string `json:"rank" validate:"required,oneof=admin member"`
+}
+
+type Ban struct {
+	ID          int64      `json:"id" gorm:"primary_key"`
+	Type        string     `json:"type" gorm:"size:20;index:idx_ban_lookup"`
+	Value       string     `json:"value" gorm:"index:idx_ban_lookup"`
+	PlayerId    *int64     `json:"player_id" gorm:"index"`
+	Reason      string     `json:"reason"`
+	IssuerId    int64      `json:"issuer_id"`
+	IsPermanent bool       `json:"is_permanent" gorm:"default:false"`
+	ExpiresAt   *time.Time `json:"expires_at" gorm:"type:TIMESTAMP;null;default:null"`
+	LiftedAt    *time.Time `json:"lifted_at" gorm:"type:TIMESTAMP;null;default:null"`
+	LiftedById  *int64     `json:"lifted_by_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type BanRequest struct {
+	Type        string     `json:"type" validate:"required,oneof=account ip machine email_domain"`
+	Value       string     `json:"value" validate:"required,max=255"`
+	Reason      string     `json:"reason" validate:"required,max=255"`
+	IsPermanent bool       `json:"is_permanent"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+type AuditEvent struct {
+	ID             int64     `json:"id" gorm:"primary_key"`
+	ActorId        *int64    `json:"actor_id" gorm:"index"`
+	TargetPlayerId *int64    `json:"target_player_id" gorm:"index"`
+	Action         string    `json:"action" gorm:"size:64;index"`
+	Ip             string    `json:"ip"`
+	UserAgent      string    `json:"user_agent"`
+	Metadata       string    `json:"metadata" gorm:"type:TEXT"`
+	CreatedAt      time.Time `json:"created_at" gorm:"index"`
+}
+
+type PlayerSessionRevocation struct {
+	ID        int64     `json:"id" gorm:"primary_key"`
+	PlayerId  int64     `json:"player_id" gorm:"unique_index"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+type HousekeepingPlayerUpdateRequest struct {
+	Username *string `json:"username" validate:"omitempty,min=3,max=20"`
+	Email    *string `json:"email" validate:"omitempty,email"`
+	Motto    *string `json:"motto" validate:"omitempty,max=100"`
+	Figure   *string `json:"figure" validate:"omitempty,max=255"`
+}
+
+type PlayerNameChange struct {
+	ID          int64     `json:"id" gorm:"primary_key"`
+	PlayerId    int64     `json:"player_id" gorm:"index"`
+	OldUsername string    `json:"old_username" gorm:"index"`
+	NewUsername string    `json:"new_username"`
+	Cost        int64     `json:"cost"`
+	ChangedById *int64    `json:"changed_by_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type UsernameChangeRequest struct {
+	Username string `json:"username" validate:"required,min=3,max=20"`
+	Password string `json:"password" validate:"required"`
+}
+
+type AccountDeletionRequest struct {
+	ID           int64      `json:"id" gorm:"primary_key"`
+	PlayerId     int64      `json:"player_id" gorm:"index"`
+	Token        string     `json:"token" gorm:"unique_index"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ConfirmedAt  *time.Time `json:"confirmed_at" gorm:"type:TIMESTAMP;null;default:null"`
+	ScheduledFor *time.Time `json:"scheduled_for" gorm:"type:TIMESTAMP;null;default:null;index"`
+	CancelledAt  *time.Time `json:"cancelled_at" gorm:"type:TIMESTAMP;null;default:null"`
+	CompletedAt  *time.Time `json:"completed_at" gorm:"type:TIMESTAMP;null;default:null"`
+}
+
+type SchemaMigration struct {
+	ID    int64     `json:"id" gorm:"primary_key"`
+	Name  string    `json:"name" gorm:"unique_index"`
+	RanAt time.Time `json:"ran_at"`
+}
+
+type RegistrationChallenge struct {
+	Challenge  string    `json:"challenge" gorm:"primary_key;size:64"`
+	Difficulty int       `json:"difficulty"`
+	ExpiresAt  time.Time `json:"expires_at" gorm:"index"`
+}
+
+type RateLimitBucket struct {
+	Key       string    `json:"key" gorm:"primary_key;size:191"`
+	Tokens    float64   `json:"tokens"`
+	UpdatedAt time.Time `json:"updated_at"`
+	FullAt    time.Time `json:"full_at" gorm:"index"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+type PasswordConfirmationRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type FriendRequestRequest struct {
+	Username string `json:"username" validate:"required,max=20"`
 }
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/jinzhu/gorm"
+	"golang.org/x/crypto/bcrypt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var errUsernameTaken = errors.New("username taken")
+var errUsernameReserved = errors.New("username reserved")
+var errUsernameHeld = errors.New("username held")
+
+func isReservedUsername(username string) bool {
+	for _, reserved := range strings.Split(getEnv("RESERVED_USERNAMES", ""), ",") {
+		if reserved = strings.TrimSpace(reserved); reserved != "" && strings.EqualFold(reserved, username) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func checkUsernameAvailable(playerId int64, username string) error {
+	if isReservedUsername(username) {
+		return errUsernameReserved
+	}
+
+	var taken int
+
+	var takenError = database.Model(Player{}).
+		Where("username = ?", username).
+		Where("id <> ?", playerId).
+		Count(&taken).
+		Error
+
+	if takenError != nil {
+		return takenError
+	}
+
+	if taken > 0 {
+		return errUsernameTaken
+	}
+
+	var held int
+	holdDays := getEnvAsInt("USERNAME_HOLD_DAYS", 30)
+
+	var heldError = database.Model(PlayerNameChange{}).
+		Where("old_username = ?", username).
+		Where("player_id <> ?", playerId).
+		Where("created_at > ?", time.Now().In(location).AddDate(0, 0, -holdDays)).
+		Count(&held).
+		Error
+
+	if heldError != nil {
+		return heldError
+	}
+
+	if held > 0 {
+		return errUsernameHeld
+	}
+
+	return nil
+}
+
+func changeUsername(tx *gorm.DB, player Player, username string, cost int64, actorId *int64) (PlayerNameChange, error) {
+	change := PlayerNameChange{
+		PlayerId:    player.ID,
+		OldUsername: player.Username,
+		NewUsername: username,
+		Cost:        cost,
+		ChangedById: actorId,
+		CreatedAt:   time.Now().In(location),
+	}
+
+	if err := tx.Model(Player{}).Where("id = ?", player.ID).Update("username", username).Error; err != nil {
+		return change, err
+	}
+
+	return change, tx.Create(&change).Error
+}
+
+func findRenamedPlayer(username string) (Player, error) {
+	var player Player
+	var change PlayerNameChange
+
+	var changeError = database.Model(PlayerNameChange{}).
+		Where("old_username = ?", username).
+		Order("id DESC").
+		First(&change).
+		Error
+
+	if changeError != nil {
+		return player, changeError
+	}
+
+	var playerError = database.Model(Player{}).
+		Where("id = ?", change.PlayerId).
+		First(&player).
+		Error
+
+	return player, playerError
+}
+
+func ChangeUsernameHandler(w http.ResponseWriter, r *http.Request) {
+	var req UsernameChangeRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	req.Username = strings.TrimSpace(req.Username)
+
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(player.Password), []byte(req.Password)) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Your current password is incorrect"})
+		return
+	}
+
+	if req.Username == player.Username {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "This is already your username"})
+		return
+	}
+
+	var online int
+
+	var onlineError = database.Model(PlayerData{}).
+		Where("player_id = ?", player.ID).
+		Where("is_online = ?", 1).
+		Count(&online).
+		Error
+
+	if onlineError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: onlineError.Error()})
+		return
+	}
+
+	if online > 0 {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Please leave the hotel before changing your username"})
+		return
+	}
+
+	var lastChange PlayerNameChange
+
+	var lastError = database.Model(PlayerNameChange{}).
+		Where("player_id = ?", player.ID).
+		Where("changed_by_id IS NULL").
+		Order("id DESC").
+		First(&lastChange).
+		Error
+
+	if lastError != nil && !errors.Is(lastError, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: lastError.Error()})
+		return
+	}
+
+	cooldown := getEnvAsInt("USERNAME_CHANGE_COOLDOWN_DAYS", 30)
+
+	if lastError == nil && lastChange.CreatedAt.AddDate(0, 0, cooldown).After(time.Now().In(location)) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You can change your username again after " + lastChange.CreatedAt.AddDate(0, 0, cooldown).Format("2006-01-02")})
+		return
+	}
+
+	switch availableError := checkUsernameAvailable(player.ID, req.Username); {
+	case errors.Is(availableError, errUsernameReserved):
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "This username is reserved"})
+		return
+	case errors.Is(availableError, errUsernameTaken), errors.Is(availableError, errUsernameHeld):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "The username you've chosen has been taken"})
+		return
+	case availableError != nil:
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: availableError.Error()})
+		return
+	}
+
+	cost := getEnvAsInt64("USERNAME_CHANGE_COST", 0)
+
+	var change PlayerNameChange
+
+	transactionError := database.Transaction(func(tx *gorm.DB) error {
+		if cost > 0 {
+			if _, err := adjustCurrency(tx, player.ID, "credits", -cost, "username_change", nil); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		change, err = changeUsername(tx, player, req.Username, cost, nil)
+
+		return err
+	})
+
+	if errors.Is(transactionError, errInsufficientBalance) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You don't have enough credits to change your username"})
+		return
+	}
+
+	if transactionError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: transactionError.Error()})
+		return
+	}
+
+	recordAudit(r, &player.ID, &player.ID, auditUsernameChanged, map[string]interface{}{"from": change.OldUsername, "to": change.NewUsername})
+
+	json.NewEncoder(w).Encode(newNameChangeResponses([]PlayerNameChange{change})[0])
+}
+
+func NameHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	playerId, idError := parseIdParam(r, "id")
+
+	if idError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid player id"})
+		return
+	}
+
+	var changes []PlayerNameChange
+
+	var queryError = database.Model(PlayerNameChange{}).
+		Where("player_id = ?", playerId).
+		Order("id DESC").
+		Find(&changes).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	encodeResponse(w, r, newNameChangeResponses(changes))
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/jinzhu/gorm"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type voucherRewardApplier func(tx *gorm.DB, playerId int64, reward VoucherReward) error
+
+var voucherRewardAppliers = map[string]voucherRewardApplier{
+	"currency":  applyCurrencyVoucherReward,
+	"badge":     applyBadgeVoucherReward,
+	"furniture": applyFurnitureVoucherReward,
+}
+
+var errVoucherUnavailable = errors.New("voucher unavailable")
+var errVoucherLimitReached = errors.New("voucher limit reached")
+
+func applyCurrencyVoucherReward(tx *gorm.DB, playerId int64, reward VoucherReward) error {
+	_, err := adjustCurrency(tx, playerId, reward.Currency, reward.Amount, "voucher", nil)
+	return err
+}
+
+func applyFurnitureVoucherReward(tx *gorm.DB, playerId int64, reward VoucherReward) error {
+	quantity := reward.Amount
+
+	if quantity < 1 {
+		quantity = 1
+	}
+
+	for i := int64(0); i < quantity; i++ {
+		item := PlayerFurnitureItem{
+			PlayerId:        playerId,
+			FurnitureItemId: reward.FurnitureItemId,
+			CreatedAt:       time.Now().In(location),
+		}
+
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateVoucherReward(reward VoucherRewardRequest) (string, error) {
+	if _, exists := voucherRewardAppliers[reward.Type]; !exists {
+		return "Unknown reward type " + reward.Type, nil
+	}
+
+	var found int
+
+	switch reward.Type {
+	case "currency":
+		if _, exists := currencyColumns[reward.Currency]; !exists || reward.Amount < 1 {
+			return "Currency rewards need a known currency and a positive amount", nil
+		}
+	case "badge":
+		if reward.BadgeCode == "" {
+			return "Badge rewards need a badge code", nil
+		}
+
+		if err := database.Model(Badge{}).Where("code = ?", reward.BadgeCode).Count(&found).Error; err != nil {
+			return "", err
+		}
+
+		if found == 0 {
+			return "The badge " + reward.BadgeCode + " doesn't exist", nil
+		}
+	case "furniture":
+		if reward.FurnitureItemId < 1 {
+			return "Furniture rewards need a furniture item id", nil
+		}
+
+		if reward.Amount > int64(getEnvAsInt("VOUCHER_MAX_FURNITURE_QUANTITY", 100)) {
+			return "Furniture rewards can't hand out that many items", nil
+		}
+
+		if err := database.Table("furniture_items").Where("id = ?", reward.FurnitureItemId).Count(&found).Error; err != nil {
+			return "", err
+		}
+
+		if found == 0 {
+			return "The requested furniture item doesn't exist", nil
+		}
+	}
+
+	return "", nil
+}
+
+func CreateVoucherHandler(w http.ResponseWriter, r *http.Request) {
+	var req VoucherCreateRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	for _, reward := range req.Rewards {
+		message, validationError := validateVoucherReward(reward)
+
+		if validationError != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: validationError.Error()})
+			return
+		}
+
+		if message != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(DefaultApiResponse{Message: message})
+			return
+		}
+	}
+
+	actor, actorError := getAuthenticatedPlayer(r)
+
+	if actorError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: actorError.Error()})
+		return
+	}
+
+	voucher := Voucher{
+		Code:           strings.ToUpper(req.Code),
+		MaxUses:        req.MaxUses,
+		PerPlayerLimit: req.PerPlayerLimit,
+		ExpiresAt:      req.ExpiresAt,
+		CreatedById:    actor.ID,
+		CreatedAt:      time.Now().In(location),
+	}
+
+	if voucher.Code == "" {
+		seedRandom()
+		voucher.Code = strings.ToUpper(randSeq(getEnvAsInt("VOUCHER_CODE_LENGTH", 12)))
+	}
+
+	if voucher.MaxUses == 0 {
+		voucher.MaxUses = 1
+	}
+
+	if voucher.PerPlayerLimit == 0 {
+		voucher.PerPlayerLimit = 1
+	}
+
+	for _, reward := range req.Rewards {
+		voucher.Rewards = append(voucher.Rewards, VoucherReward{
+			Type:            reward.Type,
+			Currency:        reward.Currency,
+			Amount:          reward.Amount,
+			BadgeCode:       reward.BadgeCode,
+			FurnitureItemId: reward.FurnitureItemId,
+		})
+	}
+
+	var existing int
+
+	if err := database.Model(Voucher{}).Where("code = ?", voucher.Code).Count(&existing).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	if existing > 0 {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "A voucher with this code already exists"})
+		return
+	}
+
+	if err := database.Create(&voucher).Error; err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: err.Error()})
+		return
+	}
+
+	recordAudit(r, &actor.ID, nil, auditVoucherCreated, map[string]interface{}{"voucher_id": voucher.ID, "code": voucher.Code})
+
+	json.NewEncoder(w).Encode(newVoucherResponse(voucher))
+}
+
+func VouchersHandler(w http.ResponseWriter, r *http.Request) {
+	cursor, cursorError := decodeCursor(r.URL.Query().Get("cursor"))
+
+	if cursorError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid cursor"})
+		return
+	}
+
+	limit := parseLimit(r, 25, 100)
+	query := database.Model(Voucher{}).Preload("Rewards")
+
+	if cursor != nil {
+		query = query.Where("id < ?", cursor.ID)
+	}
+
+	var vouchers []Voucher
+
+	var queryError = query.
+		Order("id DESC").
+		Limit(limit + 1).
+		Find(&vouchers).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	nextCursor := ""
+
+	if len(vouchers) > limit {
+		vouchers = vouchers[:limit]
+		nextCursor = encodeCursor(pageCursor{ID: vouchers[len(vouchers)-1].ID})
+	}
+
+	responses := make([]VoucherResponse, 0, len(vouchers))
+
+	for _, voucher := range vouchers {
+		responses = append(responses, newVoucherResponse(voucher))
+	}
+
+	encodePage(w, r, responses, nextCursor)
+}
+
+func VoucherRedemptionsHandler(w http.ResponseWriter, r *http.Request) {
+	voucherId, idError := parseIdParam(r, "id")
+
+	if idError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid voucher id"})
+		return
+	}
+
+	cursor, cursorError := decodeCursor(r.URL.Query().Get("cursor"))
+
+	if cursorError != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid cursor"})
+		return
+	}
+
+	limit := parseLimit(r, 50, 200)
+	query := database.Model(VoucherRedemption{}).Where("voucher_id = ?", voucherId)
+
+	if cursor != nil {
+		query = query.Where("id < ?", cursor.ID)
+	}
+
+	var redemptions []VoucherRedemption
+
+	var queryError = query.
+		Order("id DESC").
+		Limit(limit + 1).
+		Find(&redemptions).
+		Error
+
+	if queryError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: queryError.Error()})
+		return
+	}
+
+	nextCursor := ""
+
+	if len(redemptions) > limit {
+		redemptions = redemptions[:limit]
+		nextCursor = encodeCursor(pageCursor{ID: redemptions[len(redemptions)-1].ID})
+	}
+
+	responses := make([]VoucherRedemptionResponse, 0, len(redemptions))
+
+	for _, redemption := range redemptions {
+		responses = append(responses, VoucherRedemptionResponse{
+			ID:         redemption.ID,
+			PlayerId:   redemption.PlayerId,
+			Ip:         redemption.Ip,
+			RedeemedAt: redemption.RedeemedAt,
+		})
+	}
+
+	encodePage(w, r, responses, nextCursor)
+}
+
+func RedeemVoucherHandler(w http.ResponseWriter, r *http.Request) {
+	var req VoucherRedeemRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Invalid JSON body"})
+		return
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "Validation failed"})
+		return
+	}
+
+	player, playerError := getAuthenticatedPlayer(r)
+
+	if playerError != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: playerError.Error()})
+		return
+	}
+
+	var voucher Voucher
+
+	transactionError := database.Transaction(func(tx *gorm.DB) error {
+		var lockError = tx.Set("gorm:query_option", "FOR UPDATE").
+			Model(Voucher{}).
+			Where("code = ?", strings.ToUpper(strings.TrimSpace(req.Code))).
+			First(&voucher).
+			Error
+
+		if errors.Is(lockError, gorm.ErrRecordNotFound) {
+			return errVoucherUnavailable
+		}
+
+		if lockError != nil {
+			return lockError
+		}
+
+		now := time.Now().In(location)
+
+		if voucher.UsesCount >= voucher.MaxUses || (voucher.ExpiresAt != nil && voucher.ExpiresAt.Before(now)) {
+			return errVoucherUnavailable
+		}
+
+		var redeemed int
+
+		var countError = tx.Model(VoucherRedemption{}).
+			Where("voucher_id = ?", voucher.ID).
+			Where("player_id = ?", player.ID).
+			Count(&redeemed).
+			Error
+
+		if countError != nil {
+			return countError
+		}
+
+		if redeemed >= voucher.PerPlayerLimit {
+			return errVoucherLimitReached
+		}
+
+		if err := tx.Where("voucher_id = ?", voucher.ID).Find(&voucher.Rewards).Error; err != nil {
+			return err
+		}
+
+		for _, reward := range voucher.Rewards {
+			applier, exists := voucherRewardAppliers[reward.Type]
+
+			if !exists {
+				return errors.New("unknown reward type " + reward.Type)
+			}
+
+			if err := applier(tx, player.ID, reward); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&voucher).UpdateColumn("uses_count", gorm.Expr("uses_count + 1")).Error; err != nil {
+			return err
+		}
+
+		redemption := VoucherRedemption{
+			VoucherId:  voucher.ID,
+			PlayerId:   player.ID,
+			Ip:         getUserIp(r),
+			RedeemedAt: now,
+		}
+
+		return tx.Create(&redemption).Error
+	})
+
+	if errors.Is(transactionError, errVoucherUnavailable) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "This voucher is invalid or has expired"})
+		return
+	}
+
+	if errors.Is(transactionError, errVoucherLimitReached) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: "You've already redeemed this voucher"})
+		return
+	}
+
+	if transactionError != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DefaultApiResponse{Message: transactionError.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(VoucherRedeemedResponse{
+		Code:    voucher.Code,
+		Rewards: newVoucherRewardResponses(voucher.Rewards),
+	})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var wordFilterOnce sync.Once
+var wordFilterPattern *regexp.Regexp
+
+func loadWordFilter() {
+	var words []string
+
+	for _, word := range strings.Split(os.Getenv("WORD_FILTER"), ",") {
+		if word = strings.TrimSpace(word); word != "" {
+			words = append(words, regexp.QuoteMeta(word))
+		}
+	}
+
+	if path := os.Getenv("WORD_FILTER_FILE"); path != "" {
+		file, err := os.Open(path)
+
+		if err != nil {
+			log.Println("Failed to load word filter:", err)
+		} else {
+			defer file.Close()
+			scanner := bufio.NewScanner(file)
+
+			for scanner.Scan() {
+				if word := strings.TrimSpace(scanner.Text()); word != "" && !strings.HasPrefix(word, "#") {
+					words = append(words, regexp.QuoteMeta(word))
+				}
+			}
+		}
+	}
+
+	if len(words) > 0 {
+		wordFilterPattern = regexp.MustCompile(`(?i)\b(?:` + strings.Join(words, "|") + `)\b`)
+	}
+}
+
+func filterWords(text string) string {
+	wordFilterOnce.Do(loadWordFilter)
+
+	if wordFilterPattern == nil {
+		return text
+	}
+
+	return wordFilterPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return strings.Repeat("*", len([]rune(match)))
+	})
+}